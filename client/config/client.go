@@ -3,7 +3,10 @@ package config
 import (
 	"errors"
 	"flare-tlc/config"
+	"fmt"
 	"math/big"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -109,6 +112,76 @@ type FinalizerConfig struct {
 
 	// Offset from the start of the voting round
 	GracePeriodEndOffset time.Duration `toml:"grace_period_end_offset"`
+	// Non-selected finalizers send at a random time in [grace period end, grace period end + jitter)
+	GracePeriodJitter time.Duration `toml:"grace_period_jitter"`
+
+	// Number of queue items finalized concurrently
+	Workers int `toml:"workers"`
+
+	// Additional chains finalized messages are relayed to
+	RelayTargets []RelayTargetConfig `toml:"relay_targets"`
+
+	// Protocol ids to finalize, all protocols are finalized if empty
+	AllowedProtocols []uint8 `toml:"allowed_protocols"`
+	// Protocol ids that are never finalized, takes precedence over allowed_protocols
+	DeniedProtocols []uint8 `toml:"denied_protocols"`
+	// Finalization priority by protocol id, higher priority protocols are finalized first.
+	// Protocols not listed have priority 0.
+	ProtocolPriority map[string]int `toml:"protocol_priority"`
+
+	// Exclude signatures of voters that signed conflicting messages for the same protocol
+	// and voting round from finalization
+	ExcludeEquivocators bool `toml:"exclude_equivocators"`
+
+	// Finalizer mode, FinalizerModeNormal or FinalizerModeShadow
+	Mode string `toml:"mode"`
+
+	// Threshold used for messages signed by the last known signing policy after the next
+	// reward epoch was expected to start (signing policy initialization is late)
+	ThresholdFallback ThresholdFallbackConfig `toml:"threshold_fallback"`
+}
+
+const (
+	// Threshold increase and start as applied by the Relay contract
+	ThresholdFallbackRelay = "relay"
+	// Configured threshold of the total weight, starting the configured number of voting rounds late
+	ThresholdFallbackFixed = "fixed"
+	// Signing policy threshold is always used
+	ThresholdFallbackDisabled = "disabled"
+)
+
+type ThresholdFallbackConfig struct {
+	// ThresholdFallbackRelay, ThresholdFallbackFixed or ThresholdFallbackDisabled
+	Mode string `toml:"mode"`
+
+	// Fixed mode only: threshold in BIPS of the total weight (1-10000), the signing policy
+	// threshold is used if it is higher
+	ThresholdBIPS uint16 `toml:"threshold_bips"`
+	// Fixed mode only: number of voting rounds after the expected start of the next reward epoch
+	// the fallback threshold is used from
+	AfterVotingRounds uint32 `toml:"after_voting_rounds"`
+}
+
+const (
+	FinalizerModeNormal = "normal"
+	// Runs the finalization pipeline and compares the result with messages relayed by others
+	// without sending any transactions
+	FinalizerModeShadow = "shadow"
+)
+
+type RelayTargetConfig struct {
+	Name  string             `toml:"name"`
+	Chain config.ChainConfig `toml:"chain"`
+	Relay common.Address     `toml:"relay"`
+
+	SenderPrivateKeyFile string `toml:"sender_private_key_file"`
+}
+
+// Private key of the relay tx sender on the target chain, set via
+// FINALIZER_RELAY_SENDER_PRIVATE_KEY_<NAME> env var
+func (cfg RelayTargetConfig) SenderPrivateKey() string {
+	envVar := fmt.Sprintf("FINALIZER_RELAY_SENDER_PRIVATE_KEY_%s", strings.ToUpper(cfg.Name))
+	return os.Getenv(envVar)
 }
 
 type GasConfig struct {
@@ -119,11 +192,65 @@ type GasConfig struct {
 
 type UptimeConfig struct {
 	SigningWindow int64 `toml:"signing_window"`
+
+	// Node uptime source, file path or URL depending on SourceType. Zero hash is voted if empty.
+	Source string `toml:"source"`
+	// UptimeSourceJSON or UptimeSourceValidators
+	SourceType string `toml:"source_type"`
+	// Nodes with uptime percentage below this value are not included in the uptime vote
+	MinUptime float64 `toml:"min_uptime"`
+	// Submit node ids with SubmitUptimeVote when the next reward epoch starts
+	SubmitVote bool `toml:"submit_vote"`
 }
 
+const (
+	// JSON file or URL with node ids and uptime percentages
+	UptimeSourceJSON = "json"
+	// Validator node P-chain API (platform.getCurrentValidators), uptimes are over the
+	// validators' staking periods and only approximate uptimes in the reward epoch
+	UptimeSourceValidators = "validators"
+)
+
 type RewardsConfig struct {
-	PathPrefix    string `toml:"hash_path_prefix"`
-	SigningWindow int64  `toml:"signing_window"`
+	PathPrefix    PathPrefixes `toml:"hash_path_prefix"`
+	SigningWindow int64        `toml:"signing_window"`
+
+	// Number of hash path prefixes that must agree on the rewards hash, all if 0
+	MinAgreement int `toml:"min_agreement"`
+
+	// Rebuild the merkle tree from the reward claims file and check it against the rewards hash file
+	VerifyClaims bool `toml:"verify_claims"`
+}
+
+// Rewards hash sources, configured as a single string or an array of strings
+type PathPrefixes []string
+
+func (p *PathPrefixes) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		if v != "" {
+			*p = PathPrefixes{v}
+		}
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("invalid hash path prefix %v", item)
+			}
+			*p = append(*p, s)
+		}
+	default:
+		return fmt.Errorf("invalid hash path prefix %v", data)
+	}
+	return nil
+}
+
+// Returns the number of sources that must agree on the rewards hash
+func (c *RewardsConfig) RequiredAgreement() int {
+	if c.MinAgreement == 0 {
+		return len(c.PathPrefix)
+	}
+	return c.MinAgreement
 }
 
 func newConfig() *ClientConfig {
@@ -134,6 +261,13 @@ func newConfig() *ClientConfig {
 		Finalizer: FinalizerConfig{
 			StartOffset:        7 * 24 * time.Hour,
 			VoterThresholdBIPS: 500,
+			Workers:            4,
+			GracePeriodJitter:  5 * time.Second,
+			Mode:               FinalizerModeNormal,
+			ThresholdFallback: ThresholdFallbackConfig{
+				Mode:          ThresholdFallbackRelay,
+				ThresholdBIPS: 6000,
+			},
 		},
 		Submit1: defaultSubmitConfig,
 		Submit2: defaultSubmitConfig,
@@ -144,6 +278,8 @@ func newConfig() *ClientConfig {
 		RegisterGas: GasConfig{GasPriceFixed: big.NewInt(0)},
 		Uptime: UptimeConfig{
 			SigningWindow: 2,
+			SourceType:    UptimeSourceJSON,
+			MinUptime:     80,
 		},
 		Rewards: RewardsConfig{
 			SigningWindow: 2,
@@ -185,6 +321,60 @@ func validateConfig(cfg *ClientConfig) error {
 	if err != nil {
 		return err
 	}
+	if cfg.Finalizer.Workers < 1 {
+		return errors.New("finalizer workers must be at least 1")
+	}
+	if cfg.Finalizer.Mode != FinalizerModeNormal && cfg.Finalizer.Mode != FinalizerModeShadow {
+		return fmt.Errorf("invalid finalizer mode %s", cfg.Finalizer.Mode)
+	}
+	if cfg.Uptime.SourceType != UptimeSourceJSON && cfg.Uptime.SourceType != UptimeSourceValidators {
+		return fmt.Errorf("invalid uptime source type %s", cfg.Uptime.SourceType)
+	}
+	if cfg.Uptime.SubmitVote && cfg.Uptime.Source == "" {
+		return errors.New("uptime source must be set to submit uptime votes")
+	}
+	if cfg.Rewards.MinAgreement < 0 || cfg.Rewards.MinAgreement > len(cfg.Rewards.PathPrefix) {
+		return fmt.Errorf("rewards min_agreement must be between 0 and the number of hash path prefixes (%d)", len(cfg.Rewards.PathPrefix))
+	}
+	err = validateThresholdFallback(&cfg.Finalizer.ThresholdFallback)
+	if err != nil {
+		return err
+	}
+	err = validateRelayTargets(cfg.Finalizer.RelayTargets)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateThresholdFallback(cfg *ThresholdFallbackConfig) error {
+	switch cfg.Mode {
+	case ThresholdFallbackRelay, ThresholdFallbackDisabled:
+		return nil
+	case ThresholdFallbackFixed:
+		if cfg.ThresholdBIPS == 0 || cfg.ThresholdBIPS > 10000 {
+			return errors.New("threshold fallback threshold_bips must be between 1 and 10000")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid threshold fallback mode %s", cfg.Mode)
+	}
+}
+
+func validateRelayTargets(targets []RelayTargetConfig) error {
+	names := make(map[string]bool)
+	for _, target := range targets {
+		if target.Name == "" {
+			return errors.New("relay target name must be set")
+		}
+		if names[target.Name] {
+			return fmt.Errorf("duplicate relay target name %s", target.Name)
+		}
+		names[target.Name] = true
+		if target.Relay == (common.Address{}) {
+			return fmt.Errorf("relay address not set for relay target %s", target.Name)
+		}
+	}
 	return nil
 }
 
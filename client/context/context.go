@@ -17,6 +17,8 @@ type ClientContext interface {
 
 type ClientFlags struct {
 	ConfigFileName string
+	// Subcommand and its arguments, the client is run if empty
+	Args []string
 	// Add additional flags here
 }
 
@@ -58,5 +60,6 @@ func parseFlags() *ClientFlags {
 
 	return &ClientFlags{
 		ConfigFileName: *cfgFlag,
+		Args:           flag.Args(),
 	}
 }
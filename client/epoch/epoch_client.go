@@ -7,19 +7,21 @@ import (
 	"flare-tlc/config"
 	"flare-tlc/logger"
 	"flare-tlc/utils/chain"
-	"flare-tlc/utils/contracts/relay"
-	"flare-tlc/utils/contracts/system"
 	"flare-tlc/utils/credentials"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
 	"math/big"
+	"time"
 )
 
 // EpochClient performs reward epoch registration and signing actions, triggered on SystemsManager contract events:
-// - Voter registration (on VoterPowerBlockSelected)
+// - Voter registration (on VoterPowerBlockSelected), confirmed on VoterRegistered and VoterRemoved
 // - Signing new signing policy (on SigningPolicyInitialized)
+// - Submitting uptime vote for the previous epoch (on RewardEpochStarted, if enabled)
 // - Signing uptime vote (on SignUptimeVoteEnabled)
 // - Signing rewards (on UptimeVoteSigned with threshold reached)
+//
+// Failed actions are retried with increasing delays until they complete or are no longer possible.
 type EpochClient struct {
 	db epochClientDB
 
@@ -35,6 +37,11 @@ type EpochClient struct {
 
 	rewardsConfig *clientConfig.RewardsConfig
 	uptimeConfig  *clientConfig.UptimeConfig
+
+	uptimeVotes         map[int64]*uptimeVote // by reward epoch, submitted and signed votes must match
+	pendingActions      *pendingActions       // failed actions, retried until they complete or expire
+	registrationMonitor *registrationMonitor  // nil if registration is disabled
+	lifecycle           *rewardEpochLifecycle // phases of recent reward epochs and completed actions
 }
 
 func NewEpochClient(ctx flarectx.ClientContext) (*EpochClient, error) {
@@ -65,10 +72,17 @@ func NewEpochClient(ctx flarectx.ClientContext) (*EpochClient, error) {
 		return nil, errors.Wrap(err, "error creating signer private key")
 	}
 
-	systemsManagerClient, err := NewSystemsManagerClient(ethClient, cfg.ContractAddresses.SystemsManager, senderTxOpts, signerPk, chainCfg.ChainID)
+	identityAddress := cfg.Identity.Address
+	if identityAddress == chain.EmptyAddress {
+		return nil, errors.New("no identity address provided")
+	}
+	logger.Debug("Identity addr %v", identityAddress)
+
+	systemsManagerClient, err := NewSystemsManagerClient(ethClient, cfg.ContractAddresses.SystemsManager, senderTxOpts, signerPk, identityAddress, chainCfg.ChainID)
 	if err != nil {
 		return nil, err
 	}
+	systemsManagerClient.SetLegacyAddresses(cfg.ContractAddresses.LegacySystemsManagers)
 
 	relayClient, err := NewRelayContractClient(
 		ethClient,
@@ -77,6 +91,7 @@ func NewEpochClient(ctx flarectx.ClientContext) (*EpochClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	relayClient.SetLegacyAddresses(cfg.ContractAddresses.LegacyRelays)
 
 	registryClient, err := NewRegistryContractClient(
 		ethClient,
@@ -89,11 +104,14 @@ func NewEpochClient(ctx flarectx.ClientContext) (*EpochClient, error) {
 		return nil, err
 	}
 
-	identityAddress := cfg.Identity.Address
-	if identityAddress == chain.EmptyAddress {
-		return nil, errors.New("no identity address provided")
+	var monitor *registrationMonitor
+	if cfg.Clients.EnabledRegistration {
+		monitor = newRegistrationMonitor(identityAddress, expectedRegistrationFromConfig(&cfg.Credentials, signerPk))
+		monitor.RegisterAPIHandlers()
 	}
-	logger.Debug("Identity addr %v", identityAddress)
+
+	lifecycle := newRewardEpochLifecycle(identityAddress)
+	lifecycle.RegisterAPIHandlers()
 
 	db := epochClientDBGorm{db: ctx.DB()}
 	return &EpochClient{
@@ -107,6 +125,10 @@ func NewEpochClient(ctx flarectx.ClientContext) (*EpochClient, error) {
 		rewardsSigningEnabled: cfg.Clients.EnabledRewardSigning,
 		rewardsConfig:         &cfg.Rewards,
 		uptimeConfig:          &cfg.Uptime,
+		uptimeVotes:           make(map[int64]*uptimeVote),
+		pendingActions:        newPendingActions(),
+		registrationMonitor:   monitor,
+		lifecycle:             lifecycle,
 	}, nil
 }
 
@@ -122,40 +144,119 @@ func (c *EpochClient) RunContext(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if c.pendingActions == nil {
+		c.pendingActions = newPendingActions()
+	}
+
+	if c.lifecycle == nil {
+		c.lifecycle = newRewardEpochLifecycle(c.identityAddress)
+	}
 
-	var vpbsListener <-chan *system.FlareSystemsManagerVotePowerBlockSelected
-	var policyListener <-chan *relay.RelaySigningPolicyInitialized
-	var uptimeEnabledListener <-chan *system.FlareSystemsManagerSignUptimeVoteEnabled
-	var uptimeSignedListener <-chan *system.FlareSystemsManagerUptimeVoteSigned
+	// Listeners feed the reward epoch lifecycle, actions are taken only if enabled
+	randomAcquisitionListener := c.systemsManagerClient.RandomAcquisitionStartedListener(ctx, c.db, epoch)
+	vpbsListener := c.systemsManagerClient.VotePowerBlockSelectedListener(ctx, c.db, epoch)
+	voterRegistrationListener := c.registryClient.VoterRegistrationListener(ctx, c.db, epoch)
+	policyListener := c.relayClient.SigningPolicyInitializedListener(ctx, c.db, epoch)
+	policySignedListener := c.systemsManagerClient.SigningPolicySignedListener(ctx, c.db, epoch)
+	epochStartedListener := c.systemsManagerClient.RewardEpochStartedListener(ctx, c.db, epoch)
+	uptimeWindow, rewardsWindow := c.signingWindows()
+	uptimeEnabledListener := c.systemsManagerClient.SignUptimeVoteEnabledListener(ctx, c.db, epoch, uptimeWindow)
+	uptimeSignedListener := c.systemsManagerClient.UptimeVoteSignedListener(ctx, c.db, epoch, rewardsWindow)
+	rewardsSignedListener := c.systemsManagerClient.RewardsSignedListener(ctx, c.db, epoch)
 
 	if c.registrationEnabled {
 		logger.Info("Waiting for VotePowerBlockSelected event to start registration")
-		vpbsListener = c.systemsManagerClient.VotePowerBlockSelectedListener(c.db, epoch)
-		policyListener = c.relayClient.SigningPolicyInitializedListener(c.db, epoch)
 	}
 	if c.uptimeVotingEnabled {
 		logger.Info("Waiting for SignUptimeVoteEnabled event to start uptime vote signing")
-		uptimeEnabledListener = c.systemsManagerClient.SignUptimeVoteEnabledListener(c.db, epoch, c.uptimeConfig.SigningWindow)
+		if c.uptimeConfig.SubmitVote {
+			logger.Info("Waiting for RewardEpochStarted event to start uptime vote submission")
+		}
 	}
 	if c.rewardsSigningEnabled {
 		logger.Info("Waiting for UptimeVoteSigned event to start rewards signing")
-		uptimeSignedListener = c.systemsManagerClient.UptimeVoteSignedListener(c.db, epoch, c.rewardsConfig.SigningWindow)
 	}
 
+	retryTicker := time.NewTicker(pendingActionsCheckInterval)
+	defer retryTicker.Stop()
+
 	for {
 		select {
+		case randomAcquisition := <-randomAcquisitionListener:
+			c.lifecycle.RandomAcquisitionStarted(randomAcquisition)
 		case powerBlockData := <-vpbsListener:
 			logger.Debug("VotePowerBlockSelected event emitted for epoch %v", powerBlockData.RewardEpochId)
-			c.registerVoter(powerBlockData.RewardEpochId)
+			c.lifecycle.VotePowerBlockSelected(powerBlockData)
+			if !c.registrationEnabled {
+				continue
+			}
+			epochId := powerBlockData.RewardEpochId
+			c.pendingActions.Run(actionRegisterVoter, epochId.Int64(), func() actionResult {
+				return c.registerVoter(epochId)
+			})
 		case signingPolicy := <-policyListener:
 			logger.Debug("SigningPolicyInitialized event emitted for epoch %v", signingPolicy.RewardEpochId)
-			c.signPolicy(signingPolicy.RewardEpochId, signingPolicy.SigningPolicyBytes)
+			c.lifecycle.SigningPolicyInitialized(signingPolicy)
+			if !c.registrationEnabled {
+				continue
+			}
+			if c.registrationMonitor != nil {
+				c.registrationMonitor.SigningPolicyInitialized(signingPolicy.RewardEpochId.Int64())
+			}
+			epochId, policy := signingPolicy.RewardEpochId, signingPolicy.SigningPolicyBytes
+			c.pendingActions.Run(actionSignPolicy, epochId.Int64(), func() actionResult {
+				return c.signPolicy(epochId, policy)
+			})
+		case policySigned := <-policySignedListener:
+			c.lifecycle.SigningPolicySigned(policySigned)
+		case registration := <-voterRegistrationListener:
+			if registration.registered != nil {
+				c.lifecycle.VoterRegistered(registration.registered)
+			}
+			if !c.registrationEnabled || c.registrationMonitor == nil {
+				continue
+			}
+			if registration.registered != nil {
+				c.registrationMonitor.VoterRegistered(registration.registered)
+			} else {
+				c.registrationMonitor.VoterRemoved(registration.removed)
+			}
+		case epochStarted := <-epochStartedListener:
+			logger.Debug("RewardEpochStarted event emitted for epoch %v", epochStarted.RewardEpochId)
+			c.lifecycle.RewardEpochStarted(epochStarted)
+			if !c.uptimeVotingEnabled || !c.uptimeConfig.SubmitVote {
+				continue
+			}
+			epochId := new(big.Int).Sub(epochStarted.RewardEpochId, big.NewInt(1))
+			if epochId.Sign() >= 0 {
+				c.pendingActions.Run(actionSubmitUptimeVote, epochId.Int64(), func() actionResult {
+					return c.submitUptimeVote(epochId)
+				})
+			}
 		case uptimeVoteEnabled := <-uptimeEnabledListener:
 			logger.Debug("SignUptimeVoteEnabled event emitted for epoch %v", uptimeVoteEnabled.RewardEpochId)
-			c.signUptimeVote(uptimeVoteEnabled.RewardEpochId)
+			c.lifecycle.SignUptimeVoteEnabled(uptimeVoteEnabled)
+			if !c.uptimeVotingEnabled {
+				continue
+			}
+			epochId := uptimeVoteEnabled.RewardEpochId
+			c.pendingActions.Run(actionSignUptimeVote, epochId.Int64(), func() actionResult {
+				return c.signUptimeVote(epochId)
+			})
 		case uptimeVoteSigned := <-uptimeSignedListener:
+			c.lifecycle.UptimeVoteSigned(uptimeVoteSigned)
+			if !c.rewardsSigningEnabled || !uptimeVoteSigned.ThresholdReached {
+				continue
+			}
 			logger.Info("Uptime vote threshold reached for epoch %v, signing rewards", uptimeVoteSigned.RewardEpochId)
-			c.signRewards(uptimeVoteSigned.RewardEpochId)
+			epochId := uptimeVoteSigned.RewardEpochId
+			c.pendingActions.Run(actionSignRewards, epochId.Int64(), func() actionResult {
+				return c.signRewards(epochId)
+			})
+		case rewardsSigned := <-rewardsSignedListener:
+			c.lifecycle.RewardsSigned(rewardsSigned)
+		case now := <-retryTicker.C:
+			c.pendingActions.RunDue(now)
 
 		case <-ctx.Done():
 			return ctx.Err()
@@ -163,73 +264,208 @@ func (c *EpochClient) RunContext(ctx context.Context) error {
 	}
 }
 
-func (c *EpochClient) registerVoter(epochId *big.Int) {
-	if !c.isFutureEpoch(epochId) {
+// Number of past reward epochs uptime vote and rewards events are read for. If signing is
+// disabled, events are read only for the lifecycle of the previous reward epoch.
+func (c *EpochClient) signingWindows() (uptime int64, rewards int64) {
+	uptime, rewards = 1, 1
+	if c.uptimeVotingEnabled {
+		uptime = c.uptimeConfig.SigningWindow
+	}
+	if c.rewardsSigningEnabled {
+		rewards = c.rewardsConfig.SigningWindow
+	}
+	return uptime, rewards
+}
+
+func (c *EpochClient) registerVoter(epochId *big.Int) actionResult {
+	if result, ok := c.checkFutureEpoch(epochId); !ok {
 		logger.Debug("Skipping registration process for old epoch %v", epochId)
-		return
+		return result
 	}
 
 	logger.Info("VotePowerBlockSelected event emitted for next epoch %v, starting registration", epochId)
+	if result, ok := c.checkRegistration(epochId); !ok {
+		return result
+	}
 	registerResult := <-c.registryClient.RegisterVoter(epochId, c.identityAddress)
 	if registerResult.Success {
 		logger.Info("RegisterVoter success")
-	} else {
-		logger.Error("RegisterVoter failed %s", registerResult.Message)
+		return actionCompleted
 	}
+	logger.Error("RegisterVoter failed %s", registerResult.Message)
+	return actionFailed
 }
 
-func (c *EpochClient) signPolicy(epochId *big.Int, policy []byte) {
-	if !c.isFutureEpoch(epochId) {
+// Checks that voter registration is open and the registry state allows our registration,
+// problems are reported and registration is not attempted
+func (c *EpochClient) checkRegistration(epochId *big.Int) (actionResult, bool) {
+	enabledResult := <-c.systemsManagerClient.IsVoterRegistrationEnabled()
+	if !enabledResult.Success {
+		logger.Error("IsVoterRegistrationEnabled failed %s", enabledResult.Message)
+		return actionFailed, false
+	}
+	if !enabledResult.Value {
+		logger.Warn("Voter registration for epoch %v is not enabled, the registration phase has ended", epochId)
+		return actionExpired, false
+	}
+
+	preflightResult := <-c.registryClient.RegistrationPreflight(epochId, c.identityAddress)
+	if !preflightResult.Success {
+		logger.Error("RegistrationPreflight failed %s", preflightResult.Message)
+		return actionFailed, false
+	}
+	preflight := preflightResult.Value
+	if preflight.alreadyRegistered {
+		logger.Info("Voter %v already registered for epoch %v", c.identityAddress, epochId)
+		return actionCompleted, false
+	}
+	if len(preflight.problems) > 0 {
+		for _, problem := range preflight.problems {
+			logger.Error("Voter registration for epoch %v is not possible: %s", epochId, problem)
+		}
+		return actionExpired, false
+	}
+	return actionCompleted, true
+}
+
+func (c *EpochClient) signPolicy(epochId *big.Int, policy []byte) actionResult {
+	if result, ok := c.checkFutureEpoch(epochId); !ok {
 		logger.Debug("Skipping policy signing for old epoch %v", epochId)
-		return
+		return result
 	}
 
 	logger.Info("SigningPolicyInitialized event emitted for next epoch %v, signing new policy", epochId)
 	signingResult := <-c.systemsManagerClient.SignNewSigningPolicy(epochId, policy)
+	if signingResult.Success && signingResult.Value == actionExpired {
+		return actionExpired
+	}
 	if signingResult.Success {
 		logger.Info("SignNewSigningPolicy success")
-	} else {
-		logger.Error("SignNewSigningPolicy failed %s", signingResult.Message)
-		return
+		return actionCompleted
+	}
+	logger.Error("SignNewSigningPolicy failed %s", signingResult.Message)
+	return actionFailed
+}
+
+// Returns the uptime vote for the epoch, computed from the uptime source on first use
+func (c *EpochClient) uptimeVote(epochId *big.Int) (*uptimeVote, error) {
+	if vote, ok := c.uptimeVotes[epochId.Int64()]; ok {
+		return vote, nil
+	}
+	vote, err := getUptimeVote(epochId, c.uptimeConfig)
+	if err != nil {
+		return nil, err
 	}
+	c.uptimeVotes[epochId.Int64()] = vote
+	for id := range c.uptimeVotes {
+		if id < epochId.Int64()-c.uptimeConfig.SigningWindow {
+			delete(c.uptimeVotes, id)
+		}
+	}
+	return vote, nil
 }
 
-func (c *EpochClient) signUptimeVote(epochId *big.Int) {
+func (c *EpochClient) submitUptimeVote(epochId *big.Int) actionResult {
+	if result, ok := c.checkSigningWindow(epochId, c.uptimeConfig.SigningWindow); !ok {
+		return result
+	}
+	vote, err := c.uptimeVote(epochId)
+	if err != nil {
+		logger.Error("error obtaining uptime vote for epoch %v: %s", epochId, err)
+		return actionFailed
+	}
+
+	logger.Info("Submitting uptime vote for epoch %v", epochId)
+	submitResult := <-c.systemsManagerClient.SubmitUptimeVote(epochId, vote.nodeIds)
+	if submitResult.Success && submitResult.Value == actionExpired {
+		return actionExpired
+	}
+	if submitResult.Success {
+		logger.Info("SubmitUptimeVote completed")
+		// there is no event of the submitted vote the lifecycle could track
+		c.lifecycle.ActionCompleted(actionSubmitUptimeVote, epochId.Int64())
+		return actionCompleted
+	}
+	logger.Error("SubmitUptimeVote failed %s", submitResult.Message)
+	return actionFailed
+}
+
+func (c *EpochClient) signUptimeVote(epochId *big.Int) actionResult {
+	if result, ok := c.checkSigningWindow(epochId, c.uptimeConfig.SigningWindow); !ok {
+		return result
+	}
 	logger.Info("SignUptimeVoteEnabled event emitted for epoch %v, signing uptime vote", epochId)
-	signUptimeVoteResult := <-c.systemsManagerClient.SignUptimeVote(epochId)
+	vote, err := c.uptimeVote(epochId)
+	if err != nil {
+		logger.Error("error obtaining uptime vote for epoch %v: %s", epochId, err)
+		return actionFailed
+	}
+	signUptimeVoteResult := <-c.systemsManagerClient.SignUptimeVote(epochId, vote.hash)
+	if signUptimeVoteResult.Success && signUptimeVoteResult.Value == actionExpired {
+		return actionExpired
+	}
 	if signUptimeVoteResult.Success {
 		logger.Info("SignUptimeVote completed")
-	} else {
-		logger.Error("SignUptimeVote failed %s", signUptimeVoteResult.Message)
-		return
+		return actionCompleted
 	}
+	logger.Error("SignUptimeVote failed %s", signUptimeVoteResult.Message)
+	return actionFailed
 }
 
-func (c *EpochClient) isFutureEpoch(epochId *big.Int) bool {
+func (c *EpochClient) currentEpochId() (*big.Int, bool) {
 	epochIdResult := <-c.systemsManagerClient.GetCurrentRewardEpochId()
 	if !epochIdResult.Success {
 		logger.Error("GetCurrentRewardEpochId failed %s", epochIdResult.Message)
-		return false
+		return nil, false
+	}
+	return epochIdResult.Value, true
+}
+
+// Checks that the epoch is in the future, the action is expired otherwise. Returns
+// false with actionFailed if the current epoch could not be obtained.
+func (c *EpochClient) checkFutureEpoch(epochId *big.Int) (actionResult, bool) {
+	currentEpochId, ok := c.currentEpochId()
+	if !ok {
+		return actionFailed, false
 	}
-	currentEpochId := epochIdResult.Value
 	if epochId.Cmp(currentEpochId) <= 0 {
 		logger.Debug("Epoch in the past: current %v >= next %v", currentEpochId, epochId)
-		return false
+		return actionExpired, false
 	}
-	return true
+	return actionCompleted, true
 }
 
-func (c *EpochClient) signRewards(epochId *big.Int) {
+// Checks that the epoch is at most window epochs before the current one, the action is expired otherwise
+func (c *EpochClient) checkSigningWindow(epochId *big.Int, window int64) (actionResult, bool) {
+	currentEpochId, ok := c.currentEpochId()
+	if !ok {
+		return actionFailed, false
+	}
+	if epochId.Int64() < currentEpochId.Int64()-window {
+		logger.Debug("Epoch %v outside of signing window: current %v, window %d", epochId, currentEpochId, window)
+		return actionExpired, false
+	}
+	return actionCompleted, true
+}
+
+func (c *EpochClient) signRewards(epochId *big.Int) actionResult {
+	if result, ok := c.checkSigningWindow(epochId, c.rewardsConfig.SigningWindow); !ok {
+		return result
+	}
 	logger.Info("Signing rewards for epoch %v", epochId)
 	hash, weightClaims, err := getRewardsHash(epochId, c.rewardsConfig)
 	if err != nil {
-		logger.Error("error obtaining reward hash data for epoch %v, restart client to retry: %s", epochId, err)
-		return
+		logger.Error("error obtaining reward hash data for epoch %v: %s", epochId, err)
+		return actionFailed
 	}
 	signingResult := <-c.systemsManagerClient.SignRewards(epochId, hash, weightClaims)
+	if signingResult.Success && signingResult.Value == actionExpired {
+		return actionExpired
+	}
 	if signingResult.Success {
 		logger.Info("SignRewards completed")
-	} else {
-		logger.Error("SignRewards failed %s", signingResult.Message)
+		return actionCompleted
 	}
+	logger.Error("SignRewards failed %s", signingResult.Message)
+	return actionFailed
 }
@@ -259,6 +259,43 @@ func TestEpochClientRegisterErr(t *testing.T) {
 	require.Empty(t, systemsManagerClient.signedPolicies)
 }
 
+func TestEpochClientRegistrationPreflightProblems(t *testing.T) {
+	systemsManagerClient := newTestSystemsManagerClient()
+	relayClient := newTestRelayClient()
+	registryClient := newTestRegistryClient()
+	registryClient.registrationProblems = []string{"voter is chilled"}
+
+	c := &EpochClient{
+		db:                   testDB{},
+		systemsManagerClient: systemsManagerClient,
+		relayClient:          relayClient,
+		registryClient:       registryClient,
+		identityAddress:      common.HexToAddress("0x123456"),
+		registrationEnabled:  true,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	eg, ctx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		return c.RunContext(ctx)
+	})
+
+	t.Log("sending test VPBS")
+	systemsManagerClient.sendTestVPBS(&system.FlareSystemsManagerVotePowerBlockSelected{
+		RewardEpochId: big.NewInt(2),
+	})
+
+	t.Log("stopping runner")
+	cancel()
+	err := eg.Wait()
+	require.True(t, errors.Is(err, context.Canceled), "unexpected error: %s", err.Error())
+
+	t.Logf("registered voters: %v", registryClient.registeredVoters)
+	require.Empty(t, registryClient.registeredVoters)
+	require.Zero(t, c.pendingActions.Len(), "registration should not be retried")
+}
+
 type testDB struct{}
 
 func (db testDB) FetchLogsByAddressAndTopic0(
@@ -295,28 +332,34 @@ func (c testSystemsManagerClient) RewardEpochFromChain() (*utils.Epoch, error) {
 }
 
 func (c testSystemsManagerClient) VotePowerBlockSelectedListener(
-	db epochClientDB, epoch *utils.Epoch,
+	ctx context.Context, db epochClientDB, epoch *utils.Epoch,
 ) <-chan *system.FlareSystemsManagerVotePowerBlockSelected {
 	return c.vpbsChan
 }
 
 func (c testSystemsManagerClient) SignNewSigningPolicy(
 	epochID *big.Int, policy []byte,
-) <-chan shared.ExecuteStatus[any] {
-	return shared.ExecuteWithRetry(func() (any, error) {
+) <-chan shared.ExecuteStatus[actionResult] {
+	return shared.ExecuteWithRetry(func() (actionResult, error) {
 		if c.signingErr != nil {
-			return nil, c.signingErr
+			return actionFailed, c.signingErr
 		}
 
 		key := epochID.String()
 
 		if _, ok := c.signedPolicies[key]; ok {
-			return nil, errors.New("already signed")
+			return actionFailed, errors.New("already signed")
 		}
 
 		c.signedPolicies[key] = policy
 
-		return nil, nil
+		return actionCompleted, nil
+	}, 1, 0)
+}
+
+func (c testSystemsManagerClient) IsVoterRegistrationEnabled() <-chan shared.ExecuteStatus[bool] {
+	return shared.ExecuteWithRetry(func() (bool, error) {
+		return true, nil
 	}, 1, 0)
 }
 
@@ -345,14 +388,15 @@ func (c testRelayClient) sendTestPolicy(policy *relay.RelaySigningPolicyInitiali
 }
 
 func (c testRelayClient) SigningPolicyInitializedListener(
-	db epochClientDB, epoch *utils.Epoch,
+	ctx context.Context, db epochClientDB, epoch *utils.Epoch,
 ) <-chan *relay.RelaySigningPolicyInitialized {
 	return c.policyChan
 }
 
 type testRegistryClient struct {
-	registeredVoters map[string]map[common.Address]bool
-	registerErr      error
+	registeredVoters     map[string]map[common.Address]bool
+	registerErr          error
+	registrationProblems []string
 }
 
 func newTestRegistryClient() testRegistryClient {
@@ -361,6 +405,21 @@ func newTestRegistryClient() testRegistryClient {
 	}
 }
 
+func (c testRegistryClient) RegistrationPreflight(
+	epochID *big.Int, address common.Address,
+) <-chan shared.ExecuteStatus[*registrationPreflight] {
+	return shared.ExecuteWithRetry(func() (*registrationPreflight, error) {
+		return &registrationPreflight{
+			alreadyRegistered: c.registeredVoters[epochID.String()][address],
+			problems:          c.registrationProblems,
+		}, nil
+	}, 1, 0)
+}
+
+func (c testRegistryClient) VoterRegistrationListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *voterRegistrationEvent {
+	return make(chan *voterRegistrationEvent)
+}
+
 func (c testRegistryClient) RegisterVoter(
 	epochID *big.Int, address common.Address,
 ) <-chan shared.ExecuteStatus[any] {
@@ -385,22 +444,44 @@ func (c testRegistryClient) RegisterVoter(
 	}, 1, 0)
 }
 
-func (c testSystemsManagerClient) SignUptimeVoteEnabledListener(db epochClientDB, epoch *utils.Epoch, i int64) <-chan *system.FlareSystemsManagerSignUptimeVoteEnabled {
+func (c testSystemsManagerClient) SignUptimeVoteEnabledListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch, i int64) <-chan *system.FlareSystemsManagerSignUptimeVoteEnabled {
 	return make(chan *system.FlareSystemsManagerSignUptimeVoteEnabled)
 }
 
-func (c testSystemsManagerClient) SignUptimeVote(b *big.Int) <-chan shared.ExecuteStatus[any] {
-	return shared.ExecuteWithRetry(func() (any, error) {
-		return nil, nil
+func (c testSystemsManagerClient) SignUptimeVote(b *big.Int, hash common.Hash) <-chan shared.ExecuteStatus[actionResult] {
+	return shared.ExecuteWithRetry(func() (actionResult, error) {
+		return actionCompleted, nil
+	}, 1, 0)
+}
+
+func (c testSystemsManagerClient) RewardEpochStartedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *system.FlareSystemsManagerRewardEpochStarted {
+	return make(chan *system.FlareSystemsManagerRewardEpochStarted)
+}
+
+func (c testSystemsManagerClient) SubmitUptimeVote(b *big.Int, nodeIds [][20]byte) <-chan shared.ExecuteStatus[actionResult] {
+	return shared.ExecuteWithRetry(func() (actionResult, error) {
+		return actionCompleted, nil
 	}, 1, 0)
 }
 
-func (c testSystemsManagerClient) UptimeVoteSignedListener(db epochClientDB, epoch *utils.Epoch, window int64) <-chan *system.FlareSystemsManagerUptimeVoteSigned {
+func (c testSystemsManagerClient) UptimeVoteSignedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch, window int64) <-chan *system.FlareSystemsManagerUptimeVoteSigned {
 	return make(chan *system.FlareSystemsManagerUptimeVoteSigned)
 }
 
-func (c testSystemsManagerClient) SignRewards(b *big.Int, hash *common.Hash, claims int) <-chan shared.ExecuteStatus[any] {
-	return shared.ExecuteWithRetry(func() (any, error) {
-		return nil, nil
+func (c testSystemsManagerClient) SignRewards(b *big.Int, hash *common.Hash, claims int) <-chan shared.ExecuteStatus[actionResult] {
+	return shared.ExecuteWithRetry(func() (actionResult, error) {
+		return actionCompleted, nil
 	}, 1, 0)
 }
+
+func (c testSystemsManagerClient) RandomAcquisitionStartedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *system.FlareSystemsManagerRandomAcquisitionStarted {
+	return make(chan *system.FlareSystemsManagerRandomAcquisitionStarted)
+}
+
+func (c testSystemsManagerClient) SigningPolicySignedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *system.FlareSystemsManagerSigningPolicySigned {
+	return make(chan *system.FlareSystemsManagerSigningPolicySigned)
+}
+
+func (c testSystemsManagerClient) RewardsSignedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *system.FlareSystemsManagerRewardsSigned {
+	return make(chan *system.FlareSystemsManagerRewardsSigned)
+}
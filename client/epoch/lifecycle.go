@@ -0,0 +1,256 @@
+package epoch
+
+import (
+	"flare-tlc/client/shared"
+	"flare-tlc/logger"
+	"flare-tlc/utils/contracts/registry"
+	"flare-tlc/utils/contracts/relay"
+	"flare-tlc/utils/contracts/system"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const lifecycleAPIPath = "epoch/lifecycle"
+
+// Number of reward epochs the lifecycle is kept for
+const lifecycleEpochs = 5
+
+// Phase of a reward epoch, phases are ordered and a reward epoch never moves to an earlier phase
+type rewardEpochPhase int
+
+const (
+	phaseUnknown              rewardEpochPhase = iota
+	phaseRandomAcquisition                     // RandomAcquisitionStarted
+	phaseVoterRegistration                     // VotePowerBlockSelected
+	phaseSigningPolicySigning                  // SigningPolicyInitialized
+	phaseSigningPolicySigned                   // SigningPolicySigned with threshold reached
+	phaseActive                                // RewardEpochStarted
+	phaseEnded                                 // RewardEpochStarted for the next reward epoch
+	phaseUptimeVoteSigning                     // SignUptimeVoteEnabled
+	phaseRewardsSigning                        // UptimeVoteSigned with threshold reached
+	phaseRewardsSigned                         // RewardsSigned with threshold reached
+)
+
+func (p rewardEpochPhase) String() string {
+	switch p {
+	case phaseRandomAcquisition:
+		return "random acquisition"
+	case phaseVoterRegistration:
+		return "voter registration"
+	case phaseSigningPolicySigning:
+		return "signing policy signing"
+	case phaseSigningPolicySigned:
+		return "signing policy signed"
+	case phaseActive:
+		return "active"
+	case phaseEnded:
+		return "ended"
+	case phaseUptimeVoteSigning:
+		return "uptime vote signing"
+	case phaseRewardsSigning:
+		return "rewards signing"
+	case phaseRewardsSigned:
+		return "rewards signed"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	rewardEpochPhaseGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "epoch_client",
+		Name:      "reward_epoch_phase",
+		Help: "Lifecycle phase of the reward epoch (1 - random acquisition, 2 - voter registration, " +
+			"3 - signing policy signing, 4 - signing policy signed, 5 - active, 6 - ended, " +
+			"7 - uptime vote signing, 8 - rewards signing, 9 - rewards signed)",
+	}, []string{"reward_epoch_id"})
+	rewardEpochActionsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "epoch_client",
+		Name:      "reward_epoch_completed_action",
+		Help:      "Actions of the voter completed in the reward epoch (1 - completed)",
+	}, []string{"reward_epoch_id", "action"})
+)
+
+type rewardEpochState struct {
+	phase          rewardEpochPhase
+	phaseTimestamp uint64 // block timestamp of the event that started the phase
+	completed      map[actionKind]bool
+}
+
+// Lifecycle of a reward epoch, as returned by the API
+type rewardEpochStatus struct {
+	RewardEpochId    int64        `json:"rewardEpochId"`
+	Phase            string       `json:"phase"`
+	PhaseTimestamp   uint64       `json:"phaseTimestamp,omitempty"`
+	CompletedActions []actionKind `json:"completedActions"`
+}
+
+// Tracks the lifecycle phase of recent reward epochs and the actions the voter completed in them,
+// from systems manager, relay and registry events. Events of different contracts may be received
+// out of order, phases only move forward.
+type rewardEpochLifecycle struct {
+	voter  common.Address
+	epochs map[int64]*rewardEpochState
+
+	sync.Mutex
+}
+
+func newRewardEpochLifecycle(voter common.Address) *rewardEpochLifecycle {
+	return &rewardEpochLifecycle{
+		voter:  voter,
+		epochs: make(map[int64]*rewardEpochState),
+	}
+}
+
+func (l *rewardEpochLifecycle) RandomAcquisitionStarted(event *system.FlareSystemsManagerRandomAcquisitionStarted) {
+	l.advance(event.RewardEpochId.Int64(), phaseRandomAcquisition, event.Timestamp)
+}
+
+func (l *rewardEpochLifecycle) VotePowerBlockSelected(event *system.FlareSystemsManagerVotePowerBlockSelected) {
+	l.advance(event.RewardEpochId.Int64(), phaseVoterRegistration, event.Timestamp)
+}
+
+func (l *rewardEpochLifecycle) VoterRegistered(event *registry.RegistryVoterRegistered) {
+	if event.Voter == l.voter {
+		l.ActionCompleted(actionRegisterVoter, event.RewardEpochId.Int64())
+	}
+}
+
+func (l *rewardEpochLifecycle) SigningPolicyInitialized(event *relay.RelaySigningPolicyInitialized) {
+	l.advance(event.RewardEpochId.Int64(), phaseSigningPolicySigning, event.Timestamp)
+}
+
+func (l *rewardEpochLifecycle) SigningPolicySigned(event *system.FlareSystemsManagerSigningPolicySigned) {
+	if event.Voter == l.voter {
+		l.ActionCompleted(actionSignPolicy, event.RewardEpochId.Int64())
+	}
+	if event.ThresholdReached {
+		l.advance(event.RewardEpochId.Int64(), phaseSigningPolicySigned, event.Timestamp)
+	}
+}
+
+// Start of the reward epoch is also the end of the previous one
+func (l *rewardEpochLifecycle) RewardEpochStarted(event *system.FlareSystemsManagerRewardEpochStarted) {
+	rewardEpochId := event.RewardEpochId.Int64()
+	l.advance(rewardEpochId, phaseActive, event.Timestamp)
+	if rewardEpochId > 0 {
+		l.advance(rewardEpochId-1, phaseEnded, event.Timestamp)
+	}
+}
+
+func (l *rewardEpochLifecycle) SignUptimeVoteEnabled(event *system.FlareSystemsManagerSignUptimeVoteEnabled) {
+	l.advance(event.RewardEpochId.Int64(), phaseUptimeVoteSigning, event.Timestamp)
+}
+
+func (l *rewardEpochLifecycle) UptimeVoteSigned(event *system.FlareSystemsManagerUptimeVoteSigned) {
+	if event.Voter == l.voter {
+		l.ActionCompleted(actionSignUptimeVote, event.RewardEpochId.Int64())
+	}
+	if event.ThresholdReached {
+		l.advance(event.RewardEpochId.Int64(), phaseRewardsSigning, event.Timestamp)
+	}
+}
+
+func (l *rewardEpochLifecycle) RewardsSigned(event *system.FlareSystemsManagerRewardsSigned) {
+	if event.Voter == l.voter {
+		l.ActionCompleted(actionSignRewards, event.RewardEpochId.Int64())
+	}
+	if event.ThresholdReached {
+		l.advance(event.RewardEpochId.Int64(), phaseRewardsSigned, event.Timestamp)
+	}
+}
+
+// Records an action of the voter completed in the reward epoch
+func (l *rewardEpochLifecycle) ActionCompleted(kind actionKind, rewardEpochId int64) {
+	l.Lock()
+	defer l.Unlock()
+
+	state := l.state(rewardEpochId)
+	if state == nil || state.completed[kind] {
+		return
+	}
+	state.completed[kind] = true
+	rewardEpochActionsGauge.WithLabelValues(strconv.FormatInt(rewardEpochId, 10), string(kind)).Set(1)
+}
+
+func (l *rewardEpochLifecycle) advance(rewardEpochId int64, phase rewardEpochPhase, timestamp uint64) {
+	l.Lock()
+	defer l.Unlock()
+
+	state := l.state(rewardEpochId)
+	if state == nil || phase <= state.phase {
+		return
+	}
+	state.phase = phase
+	state.phaseTimestamp = timestamp
+	rewardEpochPhaseGauge.WithLabelValues(strconv.FormatInt(rewardEpochId, 10)).Set(float64(phase))
+	logger.Info("Reward epoch %d entered %s phase", rewardEpochId, phase)
+}
+
+// Returns the state of the reward epoch, creating it if needed, and drops states of old reward
+// epochs. Returns nil if the reward epoch is too old to be tracked.
+func (l *rewardEpochLifecycle) state(rewardEpochId int64) *rewardEpochState {
+	if state, ok := l.epochs[rewardEpochId]; ok {
+		return state
+	}
+	latest := rewardEpochId
+	for id := range l.epochs {
+		if id > latest {
+			latest = id
+		}
+	}
+	if rewardEpochId <= latest-lifecycleEpochs {
+		return nil
+	}
+
+	state := &rewardEpochState{completed: make(map[actionKind]bool)}
+	l.epochs[rewardEpochId] = state
+	for id, old := range l.epochs {
+		if id > latest-lifecycleEpochs {
+			continue
+		}
+		label := strconv.FormatInt(id, 10)
+		rewardEpochPhaseGauge.DeleteLabelValues(label)
+		for kind := range old.completed {
+			rewardEpochActionsGauge.DeleteLabelValues(label, string(kind))
+		}
+		delete(l.epochs, id)
+	}
+	return state
+}
+
+// Returns the lifecycle of tracked reward epochs ordered by reward epoch
+func (l *rewardEpochLifecycle) Statuses() []rewardEpochStatus {
+	l.Lock()
+	defer l.Unlock()
+
+	result := make([]rewardEpochStatus, 0, len(l.epochs))
+	for id, state := range l.epochs {
+		status := rewardEpochStatus{
+			RewardEpochId:    id,
+			Phase:            state.phase.String(),
+			PhaseTimestamp:   state.phaseTimestamp,
+			CompletedActions: make([]actionKind, 0, len(state.completed)),
+		}
+		for kind := range state.completed {
+			status.CompletedActions = append(status.CompletedActions, kind)
+		}
+		sort.Slice(status.CompletedActions, func(i, j int) bool { return status.CompletedActions[i] < status.CompletedActions[j] })
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].RewardEpochId < result[j].RewardEpochId })
+	return result
+}
+
+// Registers handler for /api/epoch/lifecycle
+func (l *rewardEpochLifecycle) RegisterAPIHandlers() {
+	shared.RegisterAPIHandler(lifecycleAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		shared.WriteJSONResponse(w, l.Statuses())
+	})
+}
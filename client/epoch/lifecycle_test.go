@@ -0,0 +1,71 @@
+package epoch
+
+import (
+	"flare-tlc/utils/contracts/registry"
+	"flare-tlc/utils/contracts/relay"
+	"flare-tlc/utils/contracts/system"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewardEpochLifecycle(t *testing.T) {
+	voter := common.HexToAddress("0x01")
+	other := common.HexToAddress("0x02")
+	l := newRewardEpochLifecycle(voter)
+	phase := func(rewardEpochId string) float64 {
+		return testutil.ToFloat64(rewardEpochPhaseGauge.WithLabelValues(rewardEpochId))
+	}
+
+	l.RandomAcquisitionStarted(&system.FlareSystemsManagerRandomAcquisitionStarted{RewardEpochId: big.NewInt(10), Timestamp: 100})
+	l.VotePowerBlockSelected(&system.FlareSystemsManagerVotePowerBlockSelected{RewardEpochId: big.NewInt(10), Timestamp: 110})
+	l.VoterRegistered(&registry.RegistryVoterRegistered{Voter: other, RewardEpochId: big.NewInt(10)})
+	l.VoterRegistered(&registry.RegistryVoterRegistered{Voter: voter, RewardEpochId: big.NewInt(10)})
+	l.SigningPolicyInitialized(&relay.RelaySigningPolicyInitialized{RewardEpochId: big.NewInt(10), Timestamp: 120})
+	require.Equal(t, float64(phaseSigningPolicySigning), phase("10"))
+
+	// threshold reached before our signature is read
+	l.SigningPolicySigned(&system.FlareSystemsManagerSigningPolicySigned{RewardEpochId: big.NewInt(10), Voter: other, ThresholdReached: true, Timestamp: 130})
+	l.SigningPolicySigned(&system.FlareSystemsManagerSigningPolicySigned{RewardEpochId: big.NewInt(10), Voter: voter, Timestamp: 125})
+	require.Equal(t, float64(phaseSigningPolicySigned), phase("10"))
+
+	// events of other contracts may be read out of order, phase does not move back
+	l.RewardEpochStarted(&system.FlareSystemsManagerRewardEpochStarted{RewardEpochId: big.NewInt(10), Timestamp: 140})
+	l.VotePowerBlockSelected(&system.FlareSystemsManagerVotePowerBlockSelected{RewardEpochId: big.NewInt(10), Timestamp: 110})
+	require.Equal(t, float64(phaseActive), phase("10"))
+	require.Equal(t, float64(phaseEnded), phase("9"))
+
+	l.SignUptimeVoteEnabled(&system.FlareSystemsManagerSignUptimeVoteEnabled{RewardEpochId: big.NewInt(9), Timestamp: 150})
+	l.ActionCompleted(actionSubmitUptimeVote, 9)
+	l.UptimeVoteSigned(&system.FlareSystemsManagerUptimeVoteSigned{RewardEpochId: big.NewInt(9), Voter: voter, ThresholdReached: true, Timestamp: 160})
+	l.RewardsSigned(&system.FlareSystemsManagerRewardsSigned{RewardEpochId: big.NewInt(9), Voter: voter, Timestamp: 170})
+	require.Equal(t, float64(phaseRewardsSigning), phase("9"))
+	l.RewardsSigned(&system.FlareSystemsManagerRewardsSigned{RewardEpochId: big.NewInt(9), Voter: other, ThresholdReached: true, Timestamp: 180})
+	require.Equal(t, float64(phaseRewardsSigned), phase("9"))
+	require.Equal(t, float64(1), testutil.ToFloat64(rewardEpochActionsGauge.WithLabelValues("9", string(actionSignRewards))))
+
+	require.Equal(t, []rewardEpochStatus{
+		{
+			RewardEpochId:    9,
+			Phase:            "rewards signed",
+			PhaseTimestamp:   180,
+			CompletedActions: []actionKind{actionSignRewards, actionSignUptimeVote, actionSubmitUptimeVote},
+		},
+		{
+			RewardEpochId:    10,
+			Phase:            "active",
+			PhaseTimestamp:   140,
+			CompletedActions: []actionKind{actionRegisterVoter, actionSignPolicy},
+		},
+	}, l.Statuses())
+
+	// old reward epochs are dropped
+	l.RandomAcquisitionStarted(&system.FlareSystemsManagerRandomAcquisitionStarted{RewardEpochId: big.NewInt(9 + lifecycleEpochs), Timestamp: 200})
+	l.RewardEpochStarted(&system.FlareSystemsManagerRewardEpochStarted{RewardEpochId: big.NewInt(9), Timestamp: 50})
+	statuses := l.Statuses()
+	require.Len(t, statuses, 2)
+	require.Equal(t, int64(10), statuses[0].RewardEpochId)
+}
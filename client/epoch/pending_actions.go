@@ -0,0 +1,116 @@
+package epoch
+
+import (
+	"flare-tlc/logger"
+	"sort"
+	"time"
+)
+
+type actionKind string
+
+const (
+	actionRegisterVoter    actionKind = "register voter"
+	actionSignPolicy       actionKind = "sign signing policy"
+	actionSubmitUptimeVote actionKind = "submit uptime vote"
+	actionSignUptimeVote   actionKind = "sign uptime vote"
+	actionSignRewards      actionKind = "sign rewards"
+)
+
+type actionResult int
+
+const (
+	actionCompleted actionResult = iota
+	actionFailed                 // retried later
+	actionExpired                // no longer possible, dropped
+)
+
+const (
+	pendingActionsCheckInterval    = 10 * time.Second
+	pendingActionInitialRetryDelay = 30 * time.Second
+	pendingActionMaxRetryDelay     = 10 * time.Minute
+)
+
+type pendingActionKey struct {
+	kind    actionKind
+	epochId int64
+}
+
+type pendingAction struct {
+	pendingActionKey
+	run         func() actionResult
+	attempts    int
+	nextAttempt time.Time
+}
+
+// Actions of the epoch client that failed and are retried with increasing delays until they
+// complete or expire. Actions are kept for the lifetime of the client, not used concurrently.
+type pendingActions struct {
+	actions map[pendingActionKey]*pendingAction
+}
+
+func newPendingActions() *pendingActions {
+	return &pendingActions{
+		actions: make(map[pendingActionKey]*pendingAction),
+	}
+}
+
+// Runs the action and schedules a retry if it fails. If the same action is already pending,
+// it is replaced and run now instead of at its scheduled time.
+func (p *pendingActions) Run(kind actionKind, epochId int64, run func() actionResult) {
+	key := pendingActionKey{kind: kind, epochId: epochId}
+	action, ok := p.actions[key]
+	if !ok {
+		action = &pendingAction{pendingActionKey: key}
+	}
+	action.run = run
+	p.attempt(action, time.Now())
+}
+
+// Runs pending actions scheduled at or before now, in order of their scheduled time
+func (p *pendingActions) RunDue(now time.Time) {
+	var due []*pendingAction
+	for _, action := range p.actions {
+		if !action.nextAttempt.After(now) {
+			due = append(due, action)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].nextAttempt.Before(due[j].nextAttempt) })
+	for _, action := range due {
+		p.attempt(action, now)
+	}
+}
+
+func (p *pendingActions) attempt(action *pendingAction, now time.Time) {
+	action.attempts++
+	switch action.run() {
+	case actionCompleted:
+		if action.attempts > 1 {
+			logger.Info("Action %s for epoch %d completed after %d attempts", action.kind, action.epochId, action.attempts)
+		}
+		delete(p.actions, action.pendingActionKey)
+	case actionExpired:
+		logger.Warn("Action %s for epoch %d is no longer possible, giving up after %d attempts", action.kind, action.epochId, action.attempts)
+		delete(p.actions, action.pendingActionKey)
+	default:
+		delay := retryDelay(action.attempts)
+		action.nextAttempt = now.Add(delay)
+		p.actions[action.pendingActionKey] = action
+		logger.Info("Action %s for epoch %d failed, retrying in %v", action.kind, action.epochId, delay)
+	}
+}
+
+// Delay doubles with each failed attempt, starting at pendingActionInitialRetryDelay
+func retryDelay(attempts int) time.Duration {
+	delay := pendingActionInitialRetryDelay
+	for i := 1; i < attempts && delay < pendingActionMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > pendingActionMaxRetryDelay {
+		delay = pendingActionMaxRetryDelay
+	}
+	return delay
+}
+
+func (p *pendingActions) Len() int {
+	return len(p.actions)
+}
@@ -0,0 +1,57 @@
+package epoch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingActionsRetry(t *testing.T) {
+	p := newPendingActions()
+
+	attempts := 0
+	p.Run(actionSignRewards, 5, func() actionResult {
+		attempts++
+		if attempts < 3 {
+			return actionFailed
+		}
+		return actionCompleted
+	})
+	require.Equal(t, 1, attempts)
+	require.Equal(t, 1, p.Len())
+
+	// not due yet
+	now := time.Now()
+	p.RunDue(now)
+	require.Equal(t, 1, attempts)
+
+	now = now.Add(pendingActionInitialRetryDelay)
+	p.RunDue(now)
+	require.Equal(t, 2, attempts)
+	require.Equal(t, 1, p.Len())
+
+	// delay doubles after the second failure
+	p.RunDue(now.Add(pendingActionInitialRetryDelay))
+	require.Equal(t, 2, attempts)
+	p.RunDue(now.Add(2 * pendingActionInitialRetryDelay))
+	require.Equal(t, 3, attempts)
+	require.Equal(t, 0, p.Len())
+}
+
+func TestPendingActionsExpired(t *testing.T) {
+	p := newPendingActions()
+
+	p.Run(actionRegisterVoter, 5, func() actionResult { return actionFailed })
+	require.Equal(t, 1, p.Len())
+
+	// event for the same action replaces the pending one
+	p.Run(actionRegisterVoter, 5, func() actionResult { return actionExpired })
+	require.Equal(t, 0, p.Len())
+}
+
+func TestRetryDelay(t *testing.T) {
+	require.Equal(t, pendingActionInitialRetryDelay, retryDelay(1))
+	require.Equal(t, 2*pendingActionInitialRetryDelay, retryDelay(2))
+	require.Equal(t, pendingActionMaxRetryDelay, retryDelay(100))
+}
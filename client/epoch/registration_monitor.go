@@ -0,0 +1,234 @@
+package epoch
+
+import (
+	"crypto/ecdsa"
+	clientConfig "flare-tlc/client/config"
+	"flare-tlc/client/shared"
+	"flare-tlc/config"
+	"flare-tlc/logger"
+	"flare-tlc/utils/contracts/registry"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const registrationAPIPath = "epoch/registration"
+
+// Number of reward epochs registration status is kept for
+const registrationStatusEpochs = 10
+
+var (
+	registrationHealthStatus = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "epoch_client",
+		Name:      "registration_health_status",
+		Help:      "Status of the voter registration in the latest reward epoch (1 - ok, -1 - error)",
+	})
+	registeredRewardEpoch = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "epoch_client",
+		Name:      "registered_reward_epoch",
+		Help:      "Latest reward epoch the voter registration was confirmed for",
+	})
+	registrationWeight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "epoch_client",
+		Name:      "registration_weight",
+		Help:      "Registration weight of the voter in the latest confirmed reward epoch",
+	})
+	voterRemovals = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "epoch_client",
+		Name:      "voter_removed_total",
+		Help:      "Number of times the voter was removed from the voter set by a voter with higher weight",
+	})
+)
+
+func init() {
+	// health is reported only once a registration problem is detected
+	registrationHealthStatus.Set(float64(shared.HealthStatusOk))
+}
+
+// Addresses the voter is expected to register with, submit addresses are not checked if nil
+type expectedRegistration struct {
+	signingPolicyAddress    common.Address
+	submitAddress           *common.Address
+	submitSignaturesAddress *common.Address
+}
+
+// Registration status of the voter in a reward epoch, as seen in registry events
+type registrationStatus struct {
+	RewardEpochId           int64          `json:"rewardEpochId"`
+	Registered              bool           `json:"registered"`
+	Removed                 bool           `json:"removed"`
+	SigningPolicyAddress    common.Address `json:"signingPolicyAddress"`
+	SubmitAddress           common.Address `json:"submitAddress"`
+	SubmitSignaturesAddress common.Address `json:"submitSignaturesAddress"`
+	Weight                  *big.Int       `json:"weight,omitempty"`
+	Problems                []string       `json:"problems,omitempty"`
+}
+
+// Tracks VoterRegistered and VoterRemoved events of the voter, checks registered addresses
+// and reports the status of the latest reward epoch as health status
+type registrationMonitor struct {
+	voter    common.Address
+	expected expectedRegistration
+	statuses map[int64]*registrationStatus
+
+	sync.Mutex
+}
+
+// Signing policy address is derived from the signer key, submit addresses from protocol manager keys if configured
+func expectedRegistrationFromConfig(cfg *clientConfig.CredentialsConfig, signerPk *ecdsa.PrivateKey) expectedRegistration {
+	expected := expectedRegistration{
+		signingPolicyAddress: crypto.PubkeyToAddress(signerPk.PublicKey),
+	}
+	if pk, err := config.PrivateKeyFromConfig(cfg.ProtocolManagerSubmitPrivateKeyFile, cfg.ProtocolManagerSubmitPrivateKey); err == nil {
+		address := crypto.PubkeyToAddress(pk.PublicKey)
+		expected.submitAddress = &address
+	}
+	if pk, err := config.PrivateKeyFromConfig(cfg.ProtocolManagerSubmitSignaturesPrivateKeyFile, cfg.ProtocolManagerSubmitSignaturesPrivateKey); err == nil {
+		address := crypto.PubkeyToAddress(pk.PublicKey)
+		expected.submitSignaturesAddress = &address
+	}
+	return expected
+}
+
+func newRegistrationMonitor(voter common.Address, expected expectedRegistration) *registrationMonitor {
+	return &registrationMonitor{
+		voter:    voter,
+		expected: expected,
+		statuses: make(map[int64]*registrationStatus),
+	}
+}
+
+func (m *registrationMonitor) VoterRegistered(event *registry.RegistryVoterRegistered) {
+	if event.Voter != m.voter {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	status := &registrationStatus{
+		RewardEpochId:           event.RewardEpochId.Int64(),
+		Registered:              true,
+		SigningPolicyAddress:    event.SigningPolicyAddress,
+		SubmitAddress:           event.SubmitAddress,
+		SubmitSignaturesAddress: event.SubmitSignaturesAddress,
+		Weight:                  event.RegistrationWeight,
+	}
+	if event.SigningPolicyAddress != m.expected.signingPolicyAddress {
+		status.Problems = append(status.Problems, fmt.Sprintf(
+			"registered signing policy address %v, expected %v", event.SigningPolicyAddress, m.expected.signingPolicyAddress,
+		))
+	}
+	if m.expected.submitAddress != nil && event.SubmitAddress != *m.expected.submitAddress {
+		status.Problems = append(status.Problems, fmt.Sprintf(
+			"registered submit address %v, expected %v", event.SubmitAddress, *m.expected.submitAddress,
+		))
+	}
+	if m.expected.submitSignaturesAddress != nil && event.SubmitSignaturesAddress != *m.expected.submitSignaturesAddress {
+		status.Problems = append(status.Problems, fmt.Sprintf(
+			"registered submit signatures address %v, expected %v", event.SubmitSignaturesAddress, *m.expected.submitSignaturesAddress,
+		))
+	}
+	if event.RegistrationWeight.Sign() == 0 {
+		status.Problems = append(status.Problems, "registered with zero weight")
+	}
+
+	if len(status.Problems) == 0 {
+		logger.Info("Voter %v registration for epoch %v confirmed, weight %v", m.voter, event.RewardEpochId, event.RegistrationWeight)
+	}
+	for _, problem := range status.Problems {
+		logger.Error("Voter %v registration for epoch %v: %s", m.voter, event.RewardEpochId, problem)
+	}
+	m.statuses[status.RewardEpochId] = status
+	m.update()
+}
+
+func (m *registrationMonitor) VoterRemoved(event *registry.RegistryVoterRemoved) {
+	if event.Voter != m.voter {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	rewardEpochId := event.RewardEpochId.Int64()
+	status, ok := m.statuses[rewardEpochId]
+	if !ok {
+		status = &registrationStatus{RewardEpochId: rewardEpochId}
+		m.statuses[rewardEpochId] = status
+	}
+	status.Registered = false
+	status.Removed = true
+	status.Problems = append(status.Problems, "removed from the voter set by a voter with higher weight")
+	logger.Error("Voter %v was removed from the voter set for epoch %v by a voter with higher weight", m.voter, event.RewardEpochId)
+	voterRemovals.Inc()
+	m.update()
+}
+
+// Registration for the epoch is closed when its signing policy is initialized, reports
+// an error if the voter was not registered by then
+func (m *registrationMonitor) SigningPolicyInitialized(rewardEpochId int64) {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.statuses[rewardEpochId]; ok {
+		return
+	}
+	m.statuses[rewardEpochId] = &registrationStatus{
+		RewardEpochId: rewardEpochId,
+		Problems:      []string{"not registered when the signing policy was initialized"},
+	}
+	logger.Error("Voter %v is not registered for epoch %v", m.voter, rewardEpochId)
+	m.update()
+}
+
+// Updates metrics from the status of the latest reward epoch and drops old statuses
+func (m *registrationMonitor) update() {
+	var latest *registrationStatus
+	for _, status := range m.statuses {
+		if latest == nil || status.RewardEpochId > latest.RewardEpochId {
+			latest = status
+		}
+	}
+	for id := range m.statuses {
+		if id <= latest.RewardEpochId-registrationStatusEpochs {
+			delete(m.statuses, id)
+		}
+	}
+
+	if latest.Registered && len(latest.Problems) == 0 {
+		registrationHealthStatus.Set(float64(shared.HealthStatusOk))
+	} else {
+		registrationHealthStatus.Set(float64(shared.HealthStatusError))
+	}
+	if latest.Registered {
+		registeredRewardEpoch.Set(float64(latest.RewardEpochId))
+		weight, _ := new(big.Float).SetInt(latest.Weight).Float64()
+		registrationWeight.Set(weight)
+	}
+}
+
+// Returns registration statuses ordered by reward epoch
+func (m *registrationMonitor) Statuses() []registrationStatus {
+	m.Lock()
+	defer m.Unlock()
+
+	result := make([]registrationStatus, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].RewardEpochId < result[j].RewardEpochId })
+	return result
+}
+
+// Registers handler for /api/epoch/registration
+func (m *registrationMonitor) RegisterAPIHandlers() {
+	shared.RegisterAPIHandler(registrationAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		shared.WriteJSONResponse(w, m.Statuses())
+	})
+}
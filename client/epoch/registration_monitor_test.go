@@ -0,0 +1,62 @@
+package epoch
+
+import (
+	"flare-tlc/client/shared"
+	"flare-tlc/utils/contracts/registry"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationMonitor(t *testing.T) {
+	voter := common.HexToAddress("0x01")
+	signingPolicyAddress := common.HexToAddress("0x02")
+	submitAddress := common.HexToAddress("0x03")
+	m := newRegistrationMonitor(voter, expectedRegistration{
+		signingPolicyAddress: signingPolicyAddress,
+		submitAddress:        &submitAddress,
+	})
+	registered := func(rewardEpochId int64, submit common.Address) *registry.RegistryVoterRegistered {
+		return &registry.RegistryVoterRegistered{
+			Voter:                voter,
+			RewardEpochId:        big.NewInt(rewardEpochId),
+			SigningPolicyAddress: signingPolicyAddress,
+			SubmitAddress:        submit,
+			RegistrationWeight:   big.NewInt(1000),
+		}
+	}
+	health := func() shared.HealthStatus {
+		return shared.HealthStatus(testutil.ToFloat64(registrationHealthStatus))
+	}
+
+	// other voters are ignored
+	m.VoterRegistered(&registry.RegistryVoterRegistered{Voter: common.HexToAddress("0x04"), RewardEpochId: big.NewInt(5)})
+	require.Empty(t, m.Statuses())
+
+	m.VoterRegistered(registered(5, submitAddress))
+	require.Equal(t, shared.HealthStatusOk, health())
+	require.Equal(t, float64(5), testutil.ToFloat64(registeredRewardEpoch))
+	require.Equal(t, float64(1000), testutil.ToFloat64(registrationWeight))
+
+	// evicted by a heavier voter
+	removals := testutil.ToFloat64(voterRemovals)
+	m.VoterRemoved(&registry.RegistryVoterRemoved{Voter: voter, RewardEpochId: big.NewInt(5)})
+	require.Equal(t, shared.HealthStatusError, health())
+	require.Equal(t, removals+1, testutil.ToFloat64(voterRemovals))
+	require.True(t, m.Statuses()[0].Removed)
+
+	m.VoterRegistered(registered(6, common.HexToAddress("0x05")))
+	require.Equal(t, shared.HealthStatusError, health(), "unexpected submit address")
+
+	m.VoterRegistered(registered(7, submitAddress))
+	require.Equal(t, shared.HealthStatusOk, health())
+
+	m.SigningPolicyInitialized(7)
+	require.Equal(t, shared.HealthStatusOk, health())
+	m.SigningPolicyInitialized(8)
+	require.Equal(t, shared.HealthStatusError, health(), "not registered")
+	require.Len(t, m.Statuses(), 4)
+}
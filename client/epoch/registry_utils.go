@@ -1,13 +1,20 @@
 package epoch
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"flare-tlc/client/config"
 	"flare-tlc/client/shared"
+	"flare-tlc/database"
 	"flare-tlc/logger"
+	"flare-tlc/utils"
 	"flare-tlc/utils/chain"
+	"flare-tlc/utils/contracts/entity"
 	"flare-tlc/utils/contracts/registry"
+	"flare-tlc/utils/contracts/system"
+	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -37,7 +44,17 @@ func init() {
 }
 
 type registryContractClient interface {
+	RegistrationPreflight(nextRewardEpochId *big.Int, address common.Address) <-chan shared.ExecuteStatus[*registrationPreflight]
 	RegisterVoter(nextRewardEpochId *big.Int, address common.Address) <-chan shared.ExecuteStatus[any]
+
+	VoterRegistrationListener(context.Context, epochClientDB, *utils.Epoch) <-chan *voterRegistrationEvent
+}
+
+// Result of registration checks against the voter registry state, registration is not
+// attempted if there are problems
+type registrationPreflight struct {
+	alreadyRegistered bool
+	problems          []string
 }
 
 type registryContractClientImpl struct {
@@ -73,6 +90,142 @@ func NewRegistryContractClient(
 
 }
 
+func (r *registryContractClientImpl) RegistrationPreflight(nextRewardEpochId *big.Int, address common.Address) <-chan shared.ExecuteStatus[*registrationPreflight] {
+	return shared.ExecuteWithRetry(func() (*registrationPreflight, error) {
+		result, err := r.registrationPreflight(nextRewardEpochId, address)
+		if err != nil {
+			return nil, errors.Wrap(err, "error checking voter registration")
+		}
+		return result, nil
+	}, shared.MaxTxSendRetries, shared.TxRetryInterval)
+}
+
+func (r *registryContractClientImpl) registrationPreflight(nextRewardEpochId *big.Int, address common.Address) (*registrationPreflight, error) {
+	result := &registrationPreflight{}
+
+	registered, err := r.registry.IsVoterRegistered(nil, address, nextRewardEpochId)
+	if err != nil {
+		return nil, err
+	}
+	if registered {
+		result.alreadyRegistered = true
+		return result, nil
+	}
+
+	chilledUntil, err := r.registry.ChilledUntilRewardEpochId(nil, address)
+	if err != nil {
+		return nil, err
+	}
+	if chilledUntil.Cmp(nextRewardEpochId) > 0 {
+		result.problems = append(result.problems, fmt.Sprintf(
+			"voter %v is chilled until reward epoch %v", address, chilledUntil,
+		))
+	}
+
+	weight, err := r.registry.GetVoterRegistrationWeight(nil, address, nextRewardEpochId)
+	if err != nil {
+		return nil, err
+	}
+	if weight.Sign() == 0 {
+		result.problems = append(result.problems, fmt.Sprintf(
+			"voter %v has zero registration weight, check WNat delegations and node stakes at the vote power block", address,
+		))
+	}
+
+	maxVoters, err := r.registry.MaxVoters(nil)
+	if err != nil {
+		return nil, err
+	}
+	numVoters, err := r.registry.GetNumberOfRegisteredVoters(nil, nextRewardEpochId)
+	if err != nil {
+		return nil, err
+	}
+	if numVoters.Cmp(maxVoters) >= 0 && weight.Sign() > 0 {
+		minWeight, err := r.minRegisteredWeight(nextRewardEpochId)
+		if err != nil {
+			return nil, err
+		}
+		if weight.Cmp(minWeight) <= 0 {
+			result.problems = append(result.problems, fmt.Sprintf(
+				"voter set is full (%v voters) and weight %v does not exceed the lowest registered weight %v",
+				numVoters, weight, minWeight,
+			))
+		} else {
+			logger.Info("Voter set is full (%v voters), registration will remove the voter with the lowest weight %v", numVoters, minWeight)
+		}
+	}
+
+	publicKeyRequired, err := r.registry.PublicKeyRequired(nil)
+	if err != nil {
+		return nil, err
+	}
+	if publicKeyRequired {
+		registered, err := r.publicKeyRegistered(nextRewardEpochId, address)
+		if err != nil {
+			return nil, err
+		}
+		if !registered {
+			result.problems = append(result.problems, fmt.Sprintf(
+				"voter registration requires a public key, none is registered for voter %v in the EntityManager at the vote power block", address,
+			))
+		}
+	}
+	return result, nil
+}
+
+// Checks that the voter has a public key registered in the EntityManager at the vote power block
+// of the reward epoch, the registry reads the key at that block
+func (r *registryContractClientImpl) publicKeyRegistered(rewardEpochId *big.Int, address common.Address) (bool, error) {
+	systemsManagerAddress, err := r.registry.FlareSystemsManager(nil)
+	if err != nil {
+		return false, err
+	}
+	systemsManager, err := system.NewFlareSystemsManagerCaller(systemsManagerAddress, r.ethClient)
+	if err != nil {
+		return false, err
+	}
+	votePowerBlock, err := systemsManager.GetVotePowerBlock(nil, rewardEpochId)
+	if err != nil {
+		return false, err
+	}
+
+	entityManagerAddress, err := r.registry.EntityManager(nil)
+	if err != nil {
+		return false, err
+	}
+	entityManager, err := entity.NewEntityManagerCaller(entityManagerAddress, r.ethClient)
+	if err != nil {
+		return false, err
+	}
+	part1, part2, err := entityManager.GetPublicKeyOfAt(nil, address, new(big.Int).SetUint64(votePowerBlock))
+	if err != nil {
+		return false, err
+	}
+	return part1 != [32]byte{} || part2 != [32]byte{}, nil
+}
+
+// Returns the lowest registration weight of voters registered for the reward epoch
+func (r *registryContractClientImpl) minRegisteredWeight(rewardEpochId *big.Int) (*big.Int, error) {
+	voters, err := r.registry.GetRegisteredVoters(nil, rewardEpochId)
+	if err != nil {
+		return nil, err
+	}
+	var minWeight *big.Int
+	for _, voter := range voters {
+		weight, err := r.registry.GetVoterRegistrationWeight(nil, voter, rewardEpochId)
+		if err != nil {
+			return nil, err
+		}
+		if minWeight == nil || weight.Cmp(minWeight) < 0 {
+			minWeight = weight
+		}
+	}
+	if minWeight == nil {
+		minWeight = big.NewInt(0)
+	}
+	return minWeight, nil
+}
+
 func (r *registryContractClientImpl) RegisterVoter(nextRewardEpochId *big.Int, address common.Address) <-chan shared.ExecuteStatus[any] {
 	return shared.ExecuteWithRetry(func() (any, error) {
 		err := r.sendRegisterVoter(nextRewardEpochId, address)
@@ -125,3 +278,35 @@ func (r *registryContractClientImpl) createSignature(nextRewardEpochId uint32, a
 	messageHash := crypto.Keccak256(message)
 	return crypto.Sign(accounts.TextHash(messageHash), r.signerPrivateKey)
 }
+
+// VoterRegistered or VoterRemoved event, exactly one of the fields is set
+type voterRegistrationEvent struct {
+	registered *registry.RegistryVoterRegistered
+	removed    *registry.RegistryVoterRemoved
+}
+
+// Returns VoterRegistered and VoterRemoved events in one stream, in the order they were emitted,
+// so a registration is never handled after the removal that followed it
+func (r *registryContractClientImpl) VoterRegistrationListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *voterRegistrationEvent {
+	removedTopic0 := common.HexToHash(eventTopic0(registry.RegistryMetaData, "VoterRemoved"))
+	stream := &shared.EventStream[*voterRegistrationEvent]{
+		Name:         "VoterRegistered/VoterRemoved",
+		History:      shared.NewContractHistory(r.address, nil),
+		Topic0:       eventTopic0(registry.RegistryMetaData, "VoterRegistered"),
+		ExtraTopic0s: []string{removedTopic0.Hex()},
+		Parse: func(dbLog database.Log) (*voterRegistrationEvent, error) {
+			contractLog, err := shared.ConvertDatabaseLogToChainLog(dbLog)
+			if err != nil {
+				return nil, err
+			}
+			if len(contractLog.Topics) > 0 && contractLog.Topics[0] == removedTopic0 {
+				removed, err := r.registry.RegistryFilterer.ParseVoterRemoved(*contractLog)
+				return &voterRegistrationEvent{removed: removed}, err
+			}
+			registered, err := r.registry.RegistryFilterer.ParseVoterRegistered(*contractLog)
+			return &voterRegistrationEvent{registered: registered}, err
+		},
+		StartDelay: randomDelay(),
+	}
+	return stream.Start(ctx, db, epoch.StartTime(epoch.EpochIndex(time.Now())-1))
+}
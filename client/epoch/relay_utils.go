@@ -1,9 +1,10 @@
 package epoch
 
 import (
+	"context"
 	"flare-tlc/client/shared"
+	"flare-tlc/config"
 	"flare-tlc/database"
-	"flare-tlc/logger"
 	"flare-tlc/utils"
 	"flare-tlc/utils/chain"
 	"flare-tlc/utils/contracts/relay"
@@ -14,11 +15,12 @@ import (
 )
 
 type relayContractClient interface {
-	SigningPolicyInitializedListener(epochClientDB, *utils.Epoch) <-chan *relay.RelaySigningPolicyInitialized
+	SigningPolicyInitializedListener(context.Context, epochClientDB, *utils.Epoch) <-chan *relay.RelaySigningPolicyInitialized
 }
 
 type relayContractClientImpl struct {
 	address    common.Address
+	history    *shared.ContractHistory // address and legacy addresses events are read from
 	relay      *relay.Relay
 	txVerifier *chain.TxVerifier
 }
@@ -33,43 +35,27 @@ func NewRelayContractClient(
 	}
 	return &relayContractClientImpl{
 		address:    address,
+		history:    shared.NewContractHistory(address, nil),
 		relay:      relay,
 		txVerifier: chain.NewTxVerifier(ethClient),
 	}, nil
 }
 
-func (r *relayContractClientImpl) SigningPolicyInitializedListener(db epochClientDB, epoch *utils.Epoch) <-chan *relay.RelaySigningPolicyInitialized {
-	topic0, err := chain.EventIDFromMetadata(relay.RelayMetaData, "SigningPolicyInitialized")
-	if err != nil {
-		// panic, this error is fatal
-		panic(err)
-	}
-	out := make(chan *relay.RelaySigningPolicyInitialized)
+// Sets previous relay deployments, their events are also read
+func (r *relayContractClientImpl) SetLegacyAddresses(legacy []config.LegacyContractAddress) {
+	r.history = shared.NewContractHistory(r.address, legacy)
+}
 
-	go func() {
-		randomDelay()
-		ticker := time.NewTicker(shared.EventListenerInterval)
-		eventRangeStart := epoch.StartTime(epoch.EpochIndex(time.Now()) - 1).Unix()
-		for {
-			<-ticker.C
-			now := time.Now().Unix()
-			logs, err := db.FetchLogsByAddressAndTopic0(r.address, topic0, eventRangeStart, now)
-			if err != nil {
-				logger.Error("Error fetching logs %v", err)
-				continue
-			}
-			if len(logs) > 0 {
-				policyData, err := r.parseSigningPolicyInitializedEvent(logs[len(logs)-1])
-				if err != nil {
-					logger.Error("Error parsing SigningPolicyInitialized event %v", err)
-					continue
-				}
-				out <- policyData
-				eventRangeStart = int64(policyData.Timestamp)
-			}
-		}
-	}()
-	return out
+func (r *relayContractClientImpl) SigningPolicyInitializedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *relay.RelaySigningPolicyInitialized {
+	stream := &shared.EventStream[*relay.RelaySigningPolicyInitialized]{
+		Name:        "SigningPolicyInitialized",
+		History:     r.history,
+		RewardEpoch: epoch,
+		Topic0:      eventTopic0(relay.RelayMetaData, "SigningPolicyInitialized"),
+		Parse:       r.parseSigningPolicyInitializedEvent,
+		StartDelay:  randomDelay(),
+	}
+	return stream.Start(ctx, db, epoch.StartTime(epoch.EpochIndex(time.Now())-1))
 }
 
 func (r *relayContractClientImpl) parseSigningPolicyInitializedEvent(dbLog database.Log) (*relay.RelaySigningPolicyInitialized, error) {
@@ -0,0 +1,122 @@
+package epoch
+
+import (
+	"encoding/json"
+	"flare-tlc/utils/merkle"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"math/big"
+)
+
+// Reward claim types, as defined in the RewardManager contract
+const (
+	claimTypeDirect = iota
+	claimTypeFee
+	claimTypeWNat
+	claimTypeMirror
+	claimTypeCChain
+)
+
+type rewardClaim struct {
+	RewardEpochId int64          `json:"rewardEpochId"`
+	Beneficiary   common.Address `json:"beneficiary"`
+	Amount        json.Number    `json:"amount"`
+	ClaimType     uint8          `json:"claimType"`
+}
+
+type rewardClaims struct {
+	RewardEpochId int64 `json:"rewardEpochId"`
+	RewardClaims  []struct {
+		Body rewardClaim `json:"body"`
+	} `json:"rewardClaims"`
+}
+
+var (
+	uint8Ty, _           = abi.NewType("uint8", "uint8", nil)
+	uint24Ty, _          = abi.NewType("uint24", "uint24", nil)
+	uint120Ty, _         = abi.NewType("uint120", "uint120", nil)
+	bytes20Ty, _         = abi.NewType("bytes20", "bytes20", nil)
+	rewardClaimArguments = abi.Arguments{
+		{Type: uint24Ty},  // reward epoch id
+		{Type: bytes20Ty}, // beneficiary
+		{Type: uint120Ty}, // amount
+		{Type: uint8Ty},   // claim type
+	}
+)
+
+// Hash of the claim as computed by the RewardManager contract: keccak256(abi.encode(claim))
+func (c *rewardClaim) hash() (common.Hash, error) {
+	amount, ok := new(big.Int).SetString(c.Amount.String(), 10)
+	if !ok {
+		return common.Hash{}, errors.Errorf("invalid claim amount %s", c.Amount)
+	}
+	packed, err := rewardClaimArguments.Pack(big.NewInt(c.RewardEpochId), [20]byte(c.Beneficiary), amount, c.ClaimType)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "error packing reward claim")
+	}
+	return crypto.Keccak256Hash(packed), nil
+}
+
+func (c *rewardClaim) isWeightBased() bool {
+	return c.ClaimType == claimTypeWNat || c.ClaimType == claimTypeMirror || c.ClaimType == claimTypeCChain
+}
+
+// Returns the merkle root and number of weight-based claims computed from the reward claims file
+func computeRewardClaimsRoot(epochId *big.Int, data []byte) (common.Hash, int, error) {
+	var claims rewardClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return common.Hash{}, 0, errors.Wrap(err, "error decoding reward claims file")
+	}
+	if claims.RewardEpochId != epochId.Int64() {
+		return common.Hash{}, 0, errors.Errorf("invalid reward claims epoch id: %d, expected: %d", claims.RewardEpochId, epochId)
+	}
+	if len(claims.RewardClaims) == 0 {
+		return common.Hash{}, 0, errors.New("no reward claims")
+	}
+
+	hashes := make([]common.Hash, len(claims.RewardClaims))
+	weightClaims := 0
+	for i := range claims.RewardClaims {
+		claim := &claims.RewardClaims[i].Body
+		if claim.RewardEpochId != epochId.Int64() {
+			return common.Hash{}, 0, errors.Errorf("invalid epoch id %d of reward claim %d", claim.RewardEpochId, i)
+		}
+		hash, err := claim.hash()
+		if err != nil {
+			return common.Hash{}, 0, errors.Wrapf(err, "reward claim %d", i)
+		}
+		hashes[i] = hash
+		if claim.isWeightBased() {
+			weightClaims++
+		}
+	}
+	root, err := merkle.Build(hashes, false).Root()
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	return root, weightClaims, nil
+}
+
+// Fetches the reward claims file and checks the merkle root and number of weight-based claims
+// against the values from the rewards hash file
+func verifyRewardClaims(epochId *big.Int, prefix string, hash common.Hash, weightClaims int) error {
+	path := fmt.Sprintf("%s/%d/reward-distribution-data.json", prefix, epochId)
+	data, err := fetchData(path, "reward claims")
+	if err != nil {
+		return err
+	}
+	root, computedWeightClaims, err := computeRewardClaimsRoot(epochId, data)
+	if err != nil {
+		return err
+	}
+	if root != hash {
+		return errors.Errorf("rewards merkle root mismatch: computed %v, rewards hash file %v", root, hash)
+	}
+	if computedWeightClaims != weightClaims {
+		return errors.Errorf("number of weight-based claims mismatch: computed %d, rewards hash file %d", computedWeightClaims, weightClaims)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package epoch
+
+import (
+	"flare-tlc/client/config"
+	"flare-tlc/utils/merkle"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testRewardClaims = `{
+	"rewardEpochId": 3,
+	"rewardClaims": [
+		{"body": {"rewardEpochId": 3, "beneficiary": "0x0000000000000000000000000000000000000001", "amount": "1000", "claimType": 0}},
+		{"body": {"rewardEpochId": 3, "beneficiary": "0x0000000000000000000000000000000000000002", "amount": "2000", "claimType": 2}},
+		{"body": {"rewardEpochId": 3, "beneficiary": "0x0000000000000000000000000000000000000003", "amount": 3000, "claimType": 4}}
+	]
+}`
+
+// abi.encode(uint24, bytes20, uint120, uint8), bytes20 is right padded
+func testClaimHash(beneficiary byte, amount int64, claimType byte) common.Hash {
+	var address [32]byte
+	address[19] = beneficiary
+	return crypto.Keccak256Hash(
+		math.U256Bytes(big.NewInt(3)), address[:], math.U256Bytes(big.NewInt(amount)), math.U256Bytes(big.NewInt(int64(claimType))),
+	)
+}
+
+func TestComputeRewardClaimsRoot(t *testing.T) {
+	root, weightClaims, err := computeRewardClaimsRoot(big.NewInt(3), []byte(testRewardClaims))
+	require.NoError(t, err)
+	require.Equal(t, 2, weightClaims)
+
+	expected, err := merkle.Build([]common.Hash{
+		testClaimHash(1, 1000, 0), testClaimHash(2, 2000, 2), testClaimHash(3, 3000, 4),
+	}, false).Root()
+	require.NoError(t, err)
+	require.Equal(t, expected, root)
+
+	_, _, err = computeRewardClaimsRoot(big.NewInt(4), []byte(testRewardClaims))
+	require.Error(t, err, "epoch mismatch")
+}
+
+func TestGetRewardsHashVerifyClaims(t *testing.T) {
+	root, _, err := computeRewardClaimsRoot(big.NewInt(3), []byte(testRewardClaims))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		root         common.Hash
+		weightClaims int
+		valid        bool
+	}{
+		{name: "valid", root: root, weightClaims: 2, valid: true},
+		{name: "root mismatch", root: common.HexToHash("0x01"), weightClaims: 2},
+		{name: "weight claims mismatch", root: root, weightClaims: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prefix := t.TempDir()
+			dir := filepath.Join(prefix, "3")
+			require.NoError(t, os.Mkdir(dir, 0755))
+			hashFile := fmt.Sprintf(`{"rewardEpochId": 3, "noOfWeightBasedClaims": %d, "merkleRoot": "%s"}`, test.weightClaims, test.root.Hex())
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "rewards-hash.json"), []byte(hashFile), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "reward-distribution-data.json"), []byte(testRewardClaims), 0644))
+
+			hash, _, err := getRewardsHash(big.NewInt(3), &config.RewardsConfig{PathPrefix: config.PathPrefixes{prefix}, VerifyClaims: true})
+			if test.valid {
+				require.NoError(t, err)
+				require.Equal(t, root, *hash)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
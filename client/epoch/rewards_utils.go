@@ -66,45 +66,115 @@ func encodeRewardsData(epochId *big.Int, chainId int, rewardHash *common.Hash, w
 	return packed
 }
 
+// Rewards hash data read from a single source
+type rewardsHashResult struct {
+	prefix       string
+	hash         common.Hash
+	weightClaims int
+}
+
+// Returns the rewards hash and number of weight-based claims agreed on by the required number of sources
 func getRewardsHash(epochId *big.Int, rewardsConfig *config.RewardsConfig) (*common.Hash, int, error) {
-	prefix := rewardsConfig.PathPrefix
-	if prefix == "" {
+	if len(rewardsConfig.PathPrefix) == 0 {
 		return nil, 0, errors.New("rewards hash path prefix not set")
 	}
 
+	var results []*rewardsHashResult
+	for _, prefix := range rewardsConfig.PathPrefix {
+		result, err := getRewardsHashFromSource(epochId, prefix, rewardsConfig.VerifyClaims)
+		if err != nil {
+			logger.Warn("Error obtaining rewards hash for epoch %v from %s: %v", epochId, prefix, err)
+			continue
+		}
+		results = append(results, result)
+	}
+	return agreedRewardsHash(epochId, results, rewardsConfig.RequiredAgreement(), len(rewardsConfig.PathPrefix))
+}
+
+// Groups source results by hash and number of weight-based claims, returns values of the largest
+// group if it has at least required members and no other group has
+func agreedRewardsHash(epochId *big.Int, results []*rewardsHashResult, required int, sources int) (*common.Hash, int, error) {
+	type key struct {
+		hash         common.Hash
+		weightClaims int
+	}
+	groups := make(map[key][]string)
+	var best key
+	for _, result := range results {
+		k := key{result.hash, result.weightClaims}
+		groups[k] = append(groups[k], result.prefix)
+		if len(groups[k]) > len(groups[best]) {
+			best = k
+		}
+	}
+
+	if len(groups) > 1 {
+		logger.Warn("Rewards hash sources disagree for epoch %v:", epochId)
+		for k, prefixes := range groups {
+			logger.Warn("  merkle root %v, weight-based claims %d: %v", k.hash, k.weightClaims, prefixes)
+		}
+	}
+	agreed := len(groups[best])
+	if agreed < required {
+		return nil, 0, errors.Errorf("rewards hash agreed by %d of %d sources, %d required", agreed, sources, required)
+	}
+	// with min agreement of at most half of the sources, conflicting hashes may both be agreed
+	for k, prefixes := range groups {
+		if k != best && len(prefixes) >= required {
+			return nil, 0, errors.Errorf("conflicting rewards hashes agreed by at least %d sources", required)
+		}
+	}
+	logger.Info("Rewards hash for epoch %v agreed by %d of %d sources: %v", epochId, agreed, sources, best.hash)
+	hash := best.hash
+	return &hash, best.weightClaims, nil
+}
+
+func getRewardsHashFromSource(epochId *big.Int, prefix string, verifyClaims bool) (*rewardsHashResult, error) {
 	path := fmt.Sprintf("%s/%d/rewards-hash.json", prefix, epochId)
 	bytes, err := fetchRewardsHashBytes(path)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	var rewardHash rewardsHash
 	err = json.Unmarshal(bytes, &rewardHash)
 	if err != nil {
-		return nil, 0, errors.Wrap(err, "error decoding reward hash file")
+		return nil, errors.Wrap(err, "error decoding reward hash file")
 	}
 
 	if rewardHash.RewardEpochId != int(epochId.Int64()) {
-		return nil, 0, errors.Errorf("invalid rewards hash epoch id: %d, expected: %d", rewardHash.RewardEpochId, epochId)
+		return nil, errors.Errorf("invalid rewards hash epoch id: %d, expected: %d", rewardHash.RewardEpochId, epochId)
 	}
 
 	hashBytes, err := hexutil.Decode(rewardHash.MerkleRoot)
 	if err != nil {
-		return nil, 0, errors.Wrap(err, "invalid rewards merkle root")
+		return nil, errors.Wrap(err, "invalid rewards merkle root")
 	}
 	if len(hashBytes) != common.HashLength {
-		return nil, 0, errors.Errorf("invalid rewards merkle root length: %v", len(hashBytes))
+		return nil, errors.Errorf("invalid rewards merkle root length: %v", len(hashBytes))
 	}
 
 	hash := common.BytesToHash(hashBytes)
-	return &hash, rewardHash.NoOfWeightBasedClaims, nil
+	if verifyClaims {
+		err := verifyRewardClaims(epochId, prefix, hash, rewardHash.NoOfWeightBasedClaims)
+		if err != nil {
+			return nil, errors.Wrap(err, "reward claims verification failed")
+		}
+		logger.Info("Reward claims for epoch %v verified: merkle root %v", epochId, hash)
+	}
+	return &rewardsHashResult{prefix: prefix, hash: hash, weightClaims: rewardHash.NoOfWeightBasedClaims}, nil
 }
 
 func fetchRewardsHashBytes(path string) ([]byte, error) {
+	return fetchData(path, "rewards hash")
+}
+
+// Reads data from the URL or local file path, name describes the data in logs and errors
+func fetchData(path string, name string) ([]byte, error) {
 	var data []byte
 	_, isUrl := parseUrl(path)
 	if isUrl {
-		logger.Info("Fetching rewards hash from URL: %s", path)
+		logger.Info("Fetching %s from URL: %s", name, path)
 		result := <-shared.ExecuteWithRetry(func() ([]byte, error) {
 			resp, err := http.Get(path)
 			if err != nil {
@@ -119,19 +189,19 @@ func fetchRewardsHashBytes(path string) ([]byte, error) {
 		}, 3, 1*time.Second)
 
 		if !result.Success {
-			return nil, errors.Errorf("error fetching rewards hash: %s", result.Message)
+			return nil, errors.Errorf("error fetching %s: %s", name, result.Message)
 		}
 		data = result.Value
 	} else {
-		logger.Info("Fetching rewards hash from disk: %s", path)
+		logger.Info("Fetching %s from disk: %s", name, path)
 		file, err := os.Open(path)
 		if err != nil {
-			return nil, errors.Wrap(err, "error opening reward hash file")
+			return nil, errors.Wrapf(err, "error opening %s file", name)
 		}
 		defer file.Close()
 		data, err = io.ReadAll(file)
 		if err != nil {
-			return nil, errors.Wrap(err, "error reading reward hash file")
+			return nil, errors.Wrapf(err, "error reading %s file", name)
 		}
 	}
 	return data, nil
@@ -2,10 +2,14 @@ package epoch
 
 import (
 	"encoding/hex"
+	"flare-tlc/client/config"
+	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/require"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -22,3 +26,85 @@ func Test_encodeRewardsData(t *testing.T) {
 		require.Equal(t, "ac5a8c3adc6d9a499eb3bc1440a5e07b041d77b0a508bebe7429d189a41acc6a", encodedHashHex)
 	})
 }
+
+func Test_getRewardsHashAgreement(t *testing.T) {
+	agreedHash := common.HexToHash("0x01")
+	writeSource := func(t *testing.T, epochId int, hash common.Hash, weightClaims int) string {
+		prefix := t.TempDir()
+		dir := filepath.Join(prefix, "3")
+		require.NoError(t, os.Mkdir(dir, 0755))
+		data := fmt.Sprintf(`{"rewardEpochId": %d, "noOfWeightBasedClaims": %d, "merkleRoot": "%s"}`, epochId, weightClaims, hash.Hex())
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "rewards-hash.json"), []byte(data), 0644))
+		return prefix
+	}
+
+	tests := []struct {
+		name         string
+		prefixes     func(t *testing.T) config.PathPrefixes
+		minAgreement int
+		valid        bool
+	}{
+		{
+			name: "all agree",
+			prefixes: func(t *testing.T) config.PathPrefixes {
+				return config.PathPrefixes{writeSource(t, 3, agreedHash, 5), writeSource(t, 3, agreedHash, 5)}
+			},
+			valid: true,
+		},
+		{
+			name: "claim count disagreement",
+			prefixes: func(t *testing.T) config.PathPrefixes {
+				return config.PathPrefixes{writeSource(t, 3, agreedHash, 5), writeSource(t, 3, agreedHash, 6)}
+			},
+		},
+		{
+			name: "majority agrees",
+			prefixes: func(t *testing.T) config.PathPrefixes {
+				return config.PathPrefixes{
+					writeSource(t, 3, common.HexToHash("0x02"), 5), writeSource(t, 3, agreedHash, 5), writeSource(t, 3, agreedHash, 5),
+				}
+			},
+			minAgreement: 2,
+			valid:        true,
+		},
+		{
+			name: "tie of conflicting hashes",
+			prefixes: func(t *testing.T) config.PathPrefixes {
+				return config.PathPrefixes{
+					writeSource(t, 3, common.HexToHash("0x02"), 5), writeSource(t, 3, agreedHash, 5),
+				}
+			},
+			minAgreement: 1,
+		},
+		{
+			name: "conflicting hashes both agreed",
+			prefixes: func(t *testing.T) config.PathPrefixes {
+				return config.PathPrefixes{
+					writeSource(t, 3, common.HexToHash("0x02"), 5), writeSource(t, 3, common.HexToHash("0x02"), 5),
+					writeSource(t, 3, agreedHash, 5), writeSource(t, 3, agreedHash, 5), writeSource(t, 3, agreedHash, 5),
+				}
+			},
+			minAgreement: 2,
+		},
+		{
+			name: "epoch mismatch and missing source",
+			prefixes: func(t *testing.T) config.PathPrefixes {
+				return config.PathPrefixes{writeSource(t, 2, agreedHash, 5), writeSource(t, 3, agreedHash, 5), t.TempDir()}
+			},
+			minAgreement: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.RewardsConfig{PathPrefix: test.prefixes(t), MinAgreement: test.minAgreement}
+			hash, weightClaims, err := getRewardsHash(big.NewInt(3), cfg)
+			if test.valid {
+				require.NoError(t, err)
+				require.Equal(t, agreedHash, *hash)
+				require.Equal(t, 5, weightClaims)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
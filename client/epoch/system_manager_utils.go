@@ -1,8 +1,10 @@
 package epoch
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"flare-tlc/client/shared"
+	"flare-tlc/config"
 	"flare-tlc/database"
 	"flare-tlc/logger"
 	"flare-tlc/utils"
@@ -19,43 +21,41 @@ import (
 	"time"
 )
 
-var (
-	nonFatalSignNewSigningPolicyErrors = []string{
-		"new signing policy already signed",
-	}
-	nonFatalSignUptimeVoteErrors = []string{
-		"submit uptime vote already ended", "voter already signed", "uptime vote hash already signed",
-	}
-	nonFatalSignRewardsErrors = []string{
-		"rewards hash already signed", "voter already signed",
-	}
-)
-
 type systemsManagerContractClient interface {
 	RewardEpochFromChain() (*utils.Epoch, error)
 
-	VotePowerBlockSelectedListener(epochClientDB, *utils.Epoch) <-chan *system.FlareSystemsManagerVotePowerBlockSelected
-	SignNewSigningPolicy(*big.Int, []byte) <-chan shared.ExecuteStatus[any]
+	VotePowerBlockSelectedListener(context.Context, epochClientDB, *utils.Epoch) <-chan *system.FlareSystemsManagerVotePowerBlockSelected
+	IsVoterRegistrationEnabled() <-chan shared.ExecuteStatus[bool]
+	SignNewSigningPolicy(*big.Int, []byte) <-chan shared.ExecuteStatus[actionResult]
 
-	SignUptimeVoteEnabledListener(epochClientDB, *utils.Epoch, int64) <-chan *system.FlareSystemsManagerSignUptimeVoteEnabled
-	SignUptimeVote(*big.Int) <-chan shared.ExecuteStatus[any]
+	SignUptimeVoteEnabledListener(context.Context, epochClientDB, *utils.Epoch, int64) <-chan *system.FlareSystemsManagerSignUptimeVoteEnabled
+	SignUptimeVote(*big.Int, common.Hash) <-chan shared.ExecuteStatus[actionResult]
 
-	UptimeVoteSignedListener(epochClientDB, *utils.Epoch, int64) <-chan *system.FlareSystemsManagerUptimeVoteSigned
-	SignRewards(*big.Int, *common.Hash, int) <-chan shared.ExecuteStatus[any]
+	RewardEpochStartedListener(context.Context, epochClientDB, *utils.Epoch) <-chan *system.FlareSystemsManagerRewardEpochStarted
+	SubmitUptimeVote(*big.Int, [][20]byte) <-chan shared.ExecuteStatus[actionResult]
+
+	UptimeVoteSignedListener(context.Context, epochClientDB, *utils.Epoch, int64) <-chan *system.FlareSystemsManagerUptimeVoteSigned
+	SignRewards(*big.Int, *common.Hash, int) <-chan shared.ExecuteStatus[actionResult]
+
+	RandomAcquisitionStartedListener(context.Context, epochClientDB, *utils.Epoch) <-chan *system.FlareSystemsManagerRandomAcquisitionStarted
+	SigningPolicySignedListener(context.Context, epochClientDB, *utils.Epoch) <-chan *system.FlareSystemsManagerSigningPolicySigned
+	RewardsSignedListener(context.Context, epochClientDB, *utils.Epoch) <-chan *system.FlareSystemsManagerRewardsSigned
 
 	GetCurrentRewardEpochId() <-chan shared.ExecuteStatus[*big.Int]
 }
 
 type systemsManagerContractClientImpl struct {
 	address             common.Address
+	history             *shared.ContractHistory // address and legacy addresses events are read from
 	flareSystemsManager *system.FlareSystemsManager
 	senderTxOpts        *bind.TransactOpts
 	txVerifier          *chain.TxVerifier
 	signerPrivateKey    *ecdsa.PrivateKey
+	voter               common.Address // identity address, sign status is checked for
 	chainId             int
 }
 
-func NewSystemsManagerClient(ethClient *ethclient.Client, address common.Address, senderTxOpts *bind.TransactOpts, signerPrivateKey *ecdsa.PrivateKey, chainId int) (*systemsManagerContractClientImpl, error) {
+func NewSystemsManagerClient(ethClient *ethclient.Client, address common.Address, senderTxOpts *bind.TransactOpts, signerPrivateKey *ecdsa.PrivateKey, voter common.Address, chainId int) (*systemsManagerContractClientImpl, error) {
 	flareSystemsManager, err := system.NewFlareSystemsManager(address, ethClient)
 	if err != nil {
 		return nil, err
@@ -63,29 +63,40 @@ func NewSystemsManagerClient(ethClient *ethclient.Client, address common.Address
 
 	return &systemsManagerContractClientImpl{
 		address:             address,
+		history:             shared.NewContractHistory(address, nil),
 		flareSystemsManager: flareSystemsManager,
 		senderTxOpts:        senderTxOpts,
 		txVerifier:          chain.NewTxVerifier(ethClient),
 		signerPrivateKey:    signerPrivateKey,
+		voter:               voter,
 		chainId:             chainId,
 	}, nil
 }
 
-func (s *systemsManagerContractClientImpl) SignNewSigningPolicy(rewardEpochId *big.Int, signingPolicy []byte) <-chan shared.ExecuteStatus[any] {
-	return shared.ExecuteWithRetry(func() (any, error) {
-		err := s.sendSignNewSigningPolicy(rewardEpochId, signingPolicy)
+// Sets previous FlareSystemsManager deployments, their events are also read
+func (s *systemsManagerContractClientImpl) SetLegacyAddresses(legacy []config.LegacyContractAddress) {
+	s.history = shared.NewContractHistory(s.address, legacy)
+}
+
+func (s *systemsManagerContractClientImpl) SignNewSigningPolicy(rewardEpochId *big.Int, signingPolicy []byte) <-chan shared.ExecuteStatus[actionResult] {
+	return shared.ExecuteWithRetry(func() (actionResult, error) {
+		result, err := s.sendSignNewSigningPolicy(rewardEpochId, signingPolicy)
 		if err != nil {
-			return nil, errors.Wrap(err, "error sending sign new signing policy")
+			return actionFailed, errors.Wrap(err, "error sending sign new signing policy")
 		}
-		return nil, nil
+		return result, nil
 	}, shared.MaxTxSendRetries, shared.TxRetryInterval)
 }
 
-func (s *systemsManagerContractClientImpl) sendSignNewSigningPolicy(rewardEpochId *big.Int, signingPolicy []byte) error {
+func (s *systemsManagerContractClientImpl) sendSignNewSigningPolicy(rewardEpochId *big.Int, signingPolicy []byte) (actionResult, error) {
+	if result, ok, err := s.checkAction(actionSignPolicy, rewardEpochId); err != nil || !ok {
+		return result, err
+	}
+
 	newSigningPolicyHash := SigningPolicyHash(signingPolicy)
 	hashSignature, err := crypto.Sign(accounts.TextHash(newSigningPolicyHash), s.signerPrivateKey)
 	if err != nil {
-		return err
+		return actionFailed, err
 	}
 
 	signature := system.IFlareSystemsManagerSignature{
@@ -96,18 +107,14 @@ func (s *systemsManagerContractClientImpl) sendSignNewSigningPolicy(rewardEpochI
 
 	tx, err := s.flareSystemsManager.SignNewSigningPolicy(s.senderTxOpts, rewardEpochId, [32]byte(newSigningPolicyHash), signature)
 	if err != nil {
-		if shared.ExistsAsSubstring(nonFatalSignNewSigningPolicyErrors, err.Error()) {
-			logger.Info("Non fatal error sending sign new signing policy: %v", err)
-			return nil
-		}
-		return err
+		return s.failedActionResult(actionSignPolicy, rewardEpochId, err)
 	}
 	err = s.txVerifier.WaitUntilMined(s.senderTxOpts.From, tx, chain.DefaultTxTimeout)
 	if err != nil {
-		return err
+		return s.failedActionResult(actionSignPolicy, rewardEpochId, err)
 	}
 	logger.Info("New signing policy sent for epoch %v", rewardEpochId)
-	return nil
+	return actionCompleted, nil
 }
 
 func SigningPolicyHash(signingPolicy []byte) []byte {
@@ -131,37 +138,22 @@ func (s *systemsManagerContractClientImpl) GetCurrentRewardEpochId() <-chan shar
 	}, shared.MaxTxSendRetries, shared.TxRetryInterval)
 }
 
-func (s *systemsManagerContractClientImpl) VotePowerBlockSelectedListener(db epochClientDB, epoch *utils.Epoch) <-chan *system.FlareSystemsManagerVotePowerBlockSelected {
-	out := make(chan *system.FlareSystemsManagerVotePowerBlockSelected)
-	topic0, err := chain.EventIDFromMetadata(system.FlareSystemsManagerMetaData, "VotePowerBlockSelected")
-	if err != nil {
-		// panic, this error is fatal
-		panic(err)
+func (s *systemsManagerContractClientImpl) IsVoterRegistrationEnabled() <-chan shared.ExecuteStatus[bool] {
+	return shared.ExecuteWithRetry(func() (bool, error) {
+		return s.flareSystemsManager.IsVoterRegistrationEnabled(nil)
+	}, shared.MaxTxSendRetries, shared.TxRetryInterval)
+}
+
+func (s *systemsManagerContractClientImpl) VotePowerBlockSelectedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *system.FlareSystemsManagerVotePowerBlockSelected {
+	stream := &shared.EventStream[*system.FlareSystemsManagerVotePowerBlockSelected]{
+		Name:        "VotePowerBlockSelected",
+		History:     s.history,
+		RewardEpoch: epoch,
+		Topic0:      eventTopic0(system.FlareSystemsManagerMetaData, "VotePowerBlockSelected"),
+		Parse:       s.parseVotePowerBlockSelectedEvent,
+		StartDelay:  randomDelay(),
 	}
-	go func() {
-		randomDelay()
-		ticker := time.NewTicker(shared.EventListenerInterval)
-		eventRangeStart := epoch.StartTime(epoch.EpochIndex(time.Now()) - 1).Unix()
-		for {
-			<-ticker.C
-			now := time.Now().Unix()
-			logs, err := db.FetchLogsByAddressAndTopic0(s.address, topic0, eventRangeStart, now)
-			if err != nil {
-				logger.Error("Error fetching logs %v", err)
-				continue
-			}
-			if len(logs) > 0 {
-				powerBlockData, err := s.parseVotePowerBlockSelectedEvent(logs[len(logs)-1])
-				if err != nil {
-					logger.Error("Error parsing VotePowerBlockSelected event %v", err)
-					continue
-				}
-				out <- powerBlockData
-				eventRangeStart = int64(powerBlockData.Timestamp)
-			}
-		}
-	}()
-	return out
+	return stream.Start(ctx, db, epoch.StartTime(epoch.EpochIndex(time.Now())-1))
 }
 
 func (s *systemsManagerContractClientImpl) parseVotePowerBlockSelectedEvent(dbLog database.Log) (*system.FlareSystemsManagerVotePowerBlockSelected, error) {
@@ -176,41 +168,21 @@ func (s *systemsManagerContractClientImpl) RewardEpochFromChain() (*utils.Epoch,
 	return shared.RewardEpochFromChain(s.flareSystemsManager)
 }
 
-func (s *systemsManagerContractClientImpl) SignUptimeVoteEnabledListener(db epochClientDB, epoch *utils.Epoch, window int64) <-chan *system.FlareSystemsManagerSignUptimeVoteEnabled {
-	out := make(chan *system.FlareSystemsManagerSignUptimeVoteEnabled)
-	topic0, err := chain.EventIDFromMetadata(system.FlareSystemsManagerMetaData, "SignUptimeVoteEnabled")
-	if err != nil {
-		// panic, this error is fatal
-		panic(err)
+func (s *systemsManagerContractClientImpl) SignUptimeVoteEnabledListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch, window int64) <-chan *system.FlareSystemsManagerSignUptimeVoteEnabled {
+	currentEpoch := epoch.EpochIndex(time.Now())
+	logger.Info("Current epoch %d", currentEpoch)
+	stream := &shared.EventStream[*system.FlareSystemsManagerSignUptimeVoteEnabled]{
+		Name:        "SignUptimeVoteEnabled",
+		History:     s.history,
+		RewardEpoch: epoch,
+		Topic0:      eventTopic0(system.FlareSystemsManagerMetaData, "SignUptimeVoteEnabled"),
+		Parse:       s.parseSignUptimeVoteEnabledEvent,
+		Filter: func(event *system.FlareSystemsManagerSignUptimeVoteEnabled) bool {
+			return event.RewardEpochId.Int64() >= currentEpoch-window
+		},
+		StartDelay: randomDelay(),
 	}
-	go func() {
-		randomDelay()
-		ticker := time.NewTicker(shared.EventListenerInterval)
-		currentEpoch := epoch.EpochIndex(time.Now())
-		eventRangeStart := epoch.StartTime(currentEpoch - window + 1).Unix()
-		logger.Info("Current epoch %d", currentEpoch)
-		for {
-			<-ticker.C
-			now := time.Now().Unix()
-			logs, err := db.FetchLogsByAddressAndTopic0(s.address, topic0, eventRangeStart, now)
-			if err != nil {
-				logger.Error("Error fetching logs %v", err)
-				continue
-			}
-			for _, log := range logs {
-				uptimeVoteEnabled, err := s.parseSignUptimeVoteEnabledEvent(log)
-				if err != nil {
-					logger.Error("Error parsing SignUptimeVoteEnabled event %v", err)
-					continue
-				}
-				if uptimeVoteEnabled.RewardEpochId.Int64() >= (currentEpoch - window) {
-					out <- uptimeVoteEnabled
-				}
-				eventRangeStart = int64(uptimeVoteEnabled.Timestamp)
-			}
-		}
-	}()
-	return out
+	return stream.Start(ctx, db, epoch.StartTime(currentEpoch-window+1))
 }
 
 func (s *systemsManagerContractClientImpl) parseSignUptimeVoteEnabledEvent(dbLog database.Log) (*system.FlareSystemsManagerSignUptimeVoteEnabled, error) {
@@ -221,97 +193,196 @@ func (s *systemsManagerContractClientImpl) parseSignUptimeVoteEnabledEvent(dbLog
 	return s.flareSystemsManager.FlareSystemsManagerFilterer.ParseSignUptimeVoteEnabled(*contractLog)
 }
 
-func (s *systemsManagerContractClientImpl) SignUptimeVote(rewardEpochId *big.Int) <-chan shared.ExecuteStatus[any] {
-	return shared.ExecuteWithRetry(func() (any, error) {
-		err := s.sendSignUptimeVote(rewardEpochId)
+func (s *systemsManagerContractClientImpl) SignUptimeVote(rewardEpochId *big.Int, hash common.Hash) <-chan shared.ExecuteStatus[actionResult] {
+	return shared.ExecuteWithRetry(func() (actionResult, error) {
+		result, err := s.sendSignUptimeVote(rewardEpochId, hash)
 		if err != nil {
-			return nil, errors.Wrap(err, "error sending sign uptime vote")
+			return actionFailed, errors.Wrap(err, "error sending sign uptime vote")
 		}
-		return nil, nil
+		return result, nil
 	}, shared.MaxTxSendRetries, shared.TxRetryInterval)
 }
 
-func (s *systemsManagerContractClientImpl) sendSignUptimeVote(rewardEpochId *big.Int) error {
-	hash, signature, err := getUptimeSignature(rewardEpochId, s.signerPrivateKey)
+func (s *systemsManagerContractClientImpl) sendSignUptimeVote(rewardEpochId *big.Int, hash common.Hash) (actionResult, error) {
+	if result, ok, err := s.checkAction(actionSignUptimeVote, rewardEpochId); err != nil || !ok {
+		return result, err
+	}
+
+	signature, err := getUptimeSignature(rewardEpochId, hash, s.signerPrivateKey)
 	if err != nil {
-		return err
+		return actionFailed, err
 	}
 
 	tx, err := s.flareSystemsManager.SignUptimeVote(s.senderTxOpts, rewardEpochId, hash, *signature)
 	if err != nil {
-		if shared.ExistsAsSubstring(nonFatalSignUptimeVoteErrors, err.Error()) {
-			logger.Info("Non fatal error sending sign uptime vote: %v", err)
-			return nil
-		}
-		return err
+		return s.failedActionResult(actionSignUptimeVote, rewardEpochId, err)
 	}
 	err = s.txVerifier.WaitUntilMined(s.senderTxOpts.From, tx, chain.DefaultTxTimeout)
 	if err != nil {
-		return err
+		return s.failedActionResult(actionSignUptimeVote, rewardEpochId, err)
 	}
 	logger.Info("Uptime vote sent for epoch %v", rewardEpochId)
-	return nil
+	return actionCompleted, nil
+}
+
+func (s *systemsManagerContractClientImpl) RewardEpochStartedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *system.FlareSystemsManagerRewardEpochStarted {
+	stream := &shared.EventStream[*system.FlareSystemsManagerRewardEpochStarted]{
+		Name:        "RewardEpochStarted",
+		History:     s.history,
+		RewardEpoch: epoch,
+		Topic0:      eventTopic0(system.FlareSystemsManagerMetaData, "RewardEpochStarted"),
+		Parse:       s.parseRewardEpochStartedEvent,
+		StartDelay:  randomDelay(),
+	}
+	return stream.Start(ctx, db, epoch.StartTime(epoch.EpochIndex(time.Now())))
 }
 
-func (s *systemsManagerContractClientImpl) UptimeVoteSignedListener(db epochClientDB, epoch *utils.Epoch, window int64) <-chan *system.FlareSystemsManagerUptimeVoteSigned {
-	out := make(chan *system.FlareSystemsManagerUptimeVoteSigned)
-	topic0, err := chain.EventIDFromMetadata(system.FlareSystemsManagerMetaData, "UptimeVoteSigned")
+func (s *systemsManagerContractClientImpl) parseRewardEpochStartedEvent(dbLog database.Log) (*system.FlareSystemsManagerRewardEpochStarted, error) {
+	contractLog, err := shared.ConvertDatabaseLogToChainLog(dbLog)
 	if err != nil {
-		// panic, this error is fatal
-		panic(err)
+		return nil, err
 	}
-	go func() {
-		randomDelay()
-		ticker := time.NewTicker(shared.EventListenerInterval)
-		currentEpoch := epoch.EpochIndex(time.Now())
-		eventRangeStart := epoch.StartTime(currentEpoch - window + 1).Unix()
-		for {
-			<-ticker.C
-			now := time.Now().Unix()
-			logs, err := db.FetchLogsByAddressAndTopic0(s.address, topic0, eventRangeStart, now)
-			if err != nil {
-				logger.Error("Error fetching logs %v", err)
-				continue
-			}
-
-			for _, log := range logs {
-				contractLog, err := shared.ConvertDatabaseLogToChainLog(log)
-				if err != nil {
-					logger.Error("Error parsing UptimeVoteSigned database log %v", err)
-					continue
-				}
-				uptimeVoteSigned, err := s.flareSystemsManager.FlareSystemsManagerFilterer.ParseUptimeVoteSigned(*contractLog)
-				if err != nil {
-					logger.Error("Error parsing UptimeVoteSigned event %v", err)
-					continue
-				}
-				if uptimeVoteSigned.ThresholdReached && uptimeVoteSigned.RewardEpochId.Int64() >= (currentEpoch-window) {
-					out <- uptimeVoteSigned
-				}
-				eventRangeStart = int64(uptimeVoteSigned.Timestamp)
-			}
+	return s.flareSystemsManager.FlareSystemsManagerFilterer.ParseRewardEpochStarted(*contractLog)
+}
+
+func (s *systemsManagerContractClientImpl) SubmitUptimeVote(rewardEpochId *big.Int, nodeIds [][20]byte) <-chan shared.ExecuteStatus[actionResult] {
+	return shared.ExecuteWithRetry(func() (actionResult, error) {
+		result, err := s.sendSubmitUptimeVote(rewardEpochId, nodeIds)
+		if err != nil {
+			return actionFailed, errors.Wrap(err, "error sending submit uptime vote")
 		}
-	}()
-	return out
+		return result, nil
+	}, shared.MaxTxSendRetries, shared.TxRetryInterval)
+}
+
+func (s *systemsManagerContractClientImpl) sendSubmitUptimeVote(rewardEpochId *big.Int, nodeIds [][20]byte) (actionResult, error) {
+	if result, ok, err := s.checkAction(actionSubmitUptimeVote, rewardEpochId); err != nil || !ok {
+		return result, err
+	}
+
+	logger.Info("Submitting uptime vote for epoch %v: %d nodes", rewardEpochId, len(nodeIds))
+	signature, err := getSubmitUptimeVoteSignature(rewardEpochId, nodeIds, s.signerPrivateKey)
+	if err != nil {
+		return actionFailed, err
+	}
+
+	tx, err := s.flareSystemsManager.SubmitUptimeVote(s.senderTxOpts, rewardEpochId, nodeIds, *signature)
+	if err != nil {
+		return s.failedActionResult(actionSubmitUptimeVote, rewardEpochId, err)
+	}
+	err = s.txVerifier.WaitUntilMined(s.senderTxOpts.From, tx, chain.DefaultTxTimeout)
+	if err != nil {
+		return s.failedActionResult(actionSubmitUptimeVote, rewardEpochId, err)
+	}
+	logger.Info("Uptime vote submitted for epoch %v", rewardEpochId)
+	return actionCompleted, nil
+}
+
+func (s *systemsManagerContractClientImpl) UptimeVoteSignedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch, window int64) <-chan *system.FlareSystemsManagerUptimeVoteSigned {
+	currentEpoch := epoch.EpochIndex(time.Now())
+	stream := &shared.EventStream[*system.FlareSystemsManagerUptimeVoteSigned]{
+		Name:        "UptimeVoteSigned",
+		History:     s.history,
+		RewardEpoch: epoch,
+		Topic0:      eventTopic0(system.FlareSystemsManagerMetaData, "UptimeVoteSigned"),
+		Parse:       s.parseUptimeVoteSignedEvent,
+		Filter: func(event *system.FlareSystemsManagerUptimeVoteSigned) bool {
+			return event.RewardEpochId.Int64() >= currentEpoch-window
+		},
+		StartDelay: randomDelay(),
+	}
+	return stream.Start(ctx, db, epoch.StartTime(currentEpoch-window+1))
+}
+
+func (s *systemsManagerContractClientImpl) parseUptimeVoteSignedEvent(dbLog database.Log) (*system.FlareSystemsManagerUptimeVoteSigned, error) {
+	contractLog, err := shared.ConvertDatabaseLogToChainLog(dbLog)
+	if err != nil {
+		return nil, err
+	}
+	return s.flareSystemsManager.FlareSystemsManagerFilterer.ParseUptimeVoteSigned(*contractLog)
+}
+
+func (s *systemsManagerContractClientImpl) RandomAcquisitionStartedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *system.FlareSystemsManagerRandomAcquisitionStarted {
+	stream := &shared.EventStream[*system.FlareSystemsManagerRandomAcquisitionStarted]{
+		Name:        "RandomAcquisitionStarted",
+		History:     s.history,
+		RewardEpoch: epoch,
+		Topic0:      eventTopic0(system.FlareSystemsManagerMetaData, "RandomAcquisitionStarted"),
+		Parse:       s.parseRandomAcquisitionStartedEvent,
+		StartDelay:  randomDelay(),
+	}
+	return stream.Start(ctx, db, epoch.StartTime(epoch.EpochIndex(time.Now())-1))
+}
+
+func (s *systemsManagerContractClientImpl) parseRandomAcquisitionStartedEvent(dbLog database.Log) (*system.FlareSystemsManagerRandomAcquisitionStarted, error) {
+	contractLog, err := shared.ConvertDatabaseLogToChainLog(dbLog)
+	if err != nil {
+		return nil, err
+	}
+	return s.flareSystemsManager.FlareSystemsManagerFilterer.ParseRandomAcquisitionStarted(*contractLog)
 }
 
-func (s *systemsManagerContractClientImpl) SignRewards(epochId *big.Int, rewardHash *common.Hash, weightClaims int) <-chan shared.ExecuteStatus[any] {
-	return shared.ExecuteWithRetry(func() (any, error) {
-		err := s.sendSignRewards(epochId, rewardHash, weightClaims)
+func (s *systemsManagerContractClientImpl) SigningPolicySignedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *system.FlareSystemsManagerSigningPolicySigned {
+	stream := &shared.EventStream[*system.FlareSystemsManagerSigningPolicySigned]{
+		Name:        "SigningPolicySigned",
+		History:     s.history,
+		RewardEpoch: epoch,
+		Topic0:      eventTopic0(system.FlareSystemsManagerMetaData, "SigningPolicySigned"),
+		Parse:       s.parseSigningPolicySignedEvent,
+		StartDelay:  randomDelay(),
+	}
+	return stream.Start(ctx, db, epoch.StartTime(epoch.EpochIndex(time.Now())-1))
+}
+
+func (s *systemsManagerContractClientImpl) parseSigningPolicySignedEvent(dbLog database.Log) (*system.FlareSystemsManagerSigningPolicySigned, error) {
+	contractLog, err := shared.ConvertDatabaseLogToChainLog(dbLog)
+	if err != nil {
+		return nil, err
+	}
+	return s.flareSystemsManager.FlareSystemsManagerFilterer.ParseSigningPolicySigned(*contractLog)
+}
+
+func (s *systemsManagerContractClientImpl) RewardsSignedListener(ctx context.Context, db epochClientDB, epoch *utils.Epoch) <-chan *system.FlareSystemsManagerRewardsSigned {
+	stream := &shared.EventStream[*system.FlareSystemsManagerRewardsSigned]{
+		Name:        "RewardsSigned",
+		History:     s.history,
+		RewardEpoch: epoch,
+		Topic0:      eventTopic0(system.FlareSystemsManagerMetaData, "RewardsSigned"),
+		Parse:       s.parseRewardsSignedEvent,
+		StartDelay:  randomDelay(),
+	}
+	return stream.Start(ctx, db, epoch.StartTime(epoch.EpochIndex(time.Now())-1))
+}
+
+func (s *systemsManagerContractClientImpl) parseRewardsSignedEvent(dbLog database.Log) (*system.FlareSystemsManagerRewardsSigned, error) {
+	contractLog, err := shared.ConvertDatabaseLogToChainLog(dbLog)
+	if err != nil {
+		return nil, err
+	}
+	return s.flareSystemsManager.FlareSystemsManagerFilterer.ParseRewardsSigned(*contractLog)
+}
+
+func (s *systemsManagerContractClientImpl) SignRewards(epochId *big.Int, rewardHash *common.Hash, weightClaims int) <-chan shared.ExecuteStatus[actionResult] {
+	return shared.ExecuteWithRetry(func() (actionResult, error) {
+		result, err := s.sendSignRewards(epochId, rewardHash, weightClaims)
 		if err != nil {
-			return nil, errors.Wrap(err, "error sending sign rewards")
+			return actionFailed, errors.Wrap(err, "error sending sign rewards")
 		}
-		return nil, nil
+		return result, nil
 	}, shared.MaxTxSendRetries, shared.TxRetryInterval)
 }
 
-func (s *systemsManagerContractClientImpl) sendSignRewards(epochId *big.Int, rewardHash *common.Hash, weightClaims int) error {
+func (s *systemsManagerContractClientImpl) sendSignRewards(epochId *big.Int, rewardHash *common.Hash, weightClaims int) (actionResult, error) {
+	if result, ok, err := s.checkAction(actionSignRewards, epochId); err != nil || !ok {
+		return result, err
+	}
+
 	logger.Info("Signing rewards for epoch %v, hash: %s", epochId, rewardHash.Hex())
 	packed := encodeRewardsData(epochId, s.chainId, rewardHash, weightClaims)
 
 	hashSignature, err := crypto.Sign(accounts.TextHash(crypto.Keccak256(packed)), s.signerPrivateKey)
 	if err != nil {
-		return err
+		return actionFailed, err
 	}
 
 	signature := system.IFlareSystemsManagerSignature{
@@ -327,23 +398,133 @@ func (s *systemsManagerContractClientImpl) sendSignRewards(epochId *big.Int, rew
 		},
 	}, *rewardHash, signature)
 	if err != nil {
-		if shared.ExistsAsSubstring(nonFatalSignRewardsErrors, err.Error()) {
-			logger.Info("Non fatal error sending reward signature: %v", err)
-			return nil
-		}
-		return err
+		return s.failedActionResult(actionSignRewards, epochId, err)
 	}
 	err = s.txVerifier.WaitUntilMined(s.senderTxOpts.From, tx, chain.DefaultTxTimeout)
 	if err != nil {
-		return err
+		return s.failedActionResult(actionSignRewards, epochId, err)
 	}
 	logger.Info("Rewards signed for epoch %v", epochId)
 
-	return nil
+	return actionCompleted, nil
+}
+
+// Checks whether the action still has to be performed: returns completed if the voter already
+// performed it and expired if the step is over for all voters, ok is false in both cases
+func (s *systemsManagerContractClientImpl) checkAction(kind actionKind, rewardEpochId *big.Int) (result actionResult, ok bool, err error) {
+	signed, err := s.alreadySigned(kind, rewardEpochId)
+	if err != nil {
+		return actionFailed, false, err
+	}
+	if signed {
+		return actionCompleted, false, nil
+	}
+	ended, err := s.actionEnded(kind, rewardEpochId)
+	if err != nil {
+		return actionFailed, false, err
+	}
+	if ended {
+		logger.Warn("Action %s for epoch %v is over for all voters, skipping", kind, rewardEpochId)
+		return actionExpired, false, nil
+	}
+	return actionCompleted, true, nil
 }
 
-// sleep for a random duration between 0 and 1 second
-func randomDelay() {
-	randomDuration := time.Duration(rand.Intn(1000)) * time.Millisecond
-	time.Sleep(randomDuration)
+// Checks whether the step of the action is over for all voters, i.e. the signing threshold was
+// reached or the phase ended
+func (s *systemsManagerContractClientImpl) actionEnded(kind actionKind, rewardEpochId *big.Int) (bool, error) {
+	switch kind {
+	case actionSignPolicy:
+		info, err := s.flareSystemsManager.GetSigningPolicySignInfo(nil, rewardEpochId)
+		if err != nil {
+			return false, errors.Wrap(err, "error fetching signing policy sign info")
+		}
+		return info.SigningPolicySignEndBlock != 0, nil
+	case actionSubmitUptimeVote:
+		// submission ends when uptime vote signing starts
+		info, err := s.flareSystemsManager.GetUptimeVoteSignStartInfo(nil, rewardEpochId)
+		if err != nil {
+			return false, errors.Wrap(err, "error fetching uptime vote sign start info")
+		}
+		return info.UptimeVoteSignStartBlock != 0, nil
+	case actionSignUptimeVote:
+		hash, err := s.flareSystemsManager.UptimeVoteHash(nil, rewardEpochId)
+		if err != nil {
+			return false, errors.Wrap(err, "error fetching uptime vote hash")
+		}
+		return hash != [32]byte{}, nil
+	case actionSignRewards:
+		hash, err := s.flareSystemsManager.RewardsHash(nil, rewardEpochId)
+		if err != nil {
+			return false, errors.Wrap(err, "error fetching rewards hash")
+		}
+		return hash != [32]byte{}, nil
+	default:
+		return false, errors.Errorf("no sign info for action %s", kind)
+	}
+}
+
+// Result of an action tx that failed or reverted. The step may have ended or the voter may have
+// performed the action since the on-chain state was checked, so the state is checked again.
+func (s *systemsManagerContractClientImpl) failedActionResult(kind actionKind, rewardEpochId *big.Int, err error) (actionResult, error) {
+	result, ok, checkErr := s.checkAction(kind, rewardEpochId)
+	if checkErr != nil || ok {
+		return actionFailed, err
+	}
+	logger.Info("Action %s for epoch %v tx failed, but the action is no longer needed: %v", kind, rewardEpochId, err)
+	return result, nil
+}
+
+// Checks whether the voter already performed the action for the reward epoch on chain
+func (s *systemsManagerContractClientImpl) alreadySigned(kind actionKind, rewardEpochId *big.Int) (bool, error) {
+	var ts, block uint64
+	switch kind {
+	case actionSignPolicy:
+		info, err := s.flareSystemsManager.GetVoterSigningPolicySignInfo(nil, rewardEpochId, s.voter)
+		if err != nil {
+			return false, errors.Wrap(err, "error fetching signing policy sign info")
+		}
+		ts, block = info.SigningPolicySignTs, info.SigningPolicySignBlock
+	case actionSubmitUptimeVote:
+		info, err := s.flareSystemsManager.GetVoterUptimeVoteSubmitInfo(nil, rewardEpochId, s.voter)
+		if err != nil {
+			return false, errors.Wrap(err, "error fetching uptime vote submit info")
+		}
+		ts, block = info.UptimeVoteSubmitTs, info.UptimeVoteSubmitBlock
+	case actionSignUptimeVote:
+		info, err := s.flareSystemsManager.GetVoterUptimeVoteSignInfo(nil, rewardEpochId, s.voter)
+		if err != nil {
+			return false, errors.Wrap(err, "error fetching uptime vote sign info")
+		}
+		ts, block = info.UptimeVoteSignTs, info.UptimeVoteSignBlock
+	case actionSignRewards:
+		info, err := s.flareSystemsManager.GetVoterRewardsSignInfo(nil, rewardEpochId, s.voter)
+		if err != nil {
+			return false, errors.Wrap(err, "error fetching rewards sign info")
+		}
+		ts, block = info.RewardsSignTs, info.RewardsSignBlock
+	default:
+		return false, errors.Errorf("no sign info for action %s", kind)
+	}
+	if block == 0 {
+		return false, nil
+	}
+	logger.Info("Action %s for epoch %v already done by voter %v in block %d (%v), skipping",
+		kind, rewardEpochId, s.voter, block, time.Unix(int64(ts), 0))
+	return true, nil
+}
+
+// Random delay before the first poll of a listener, so listeners do not query the database at the same time
+func randomDelay() time.Duration {
+	return time.Duration(rand.Intn(1000)) * time.Millisecond
+}
+
+// Returns the topic0 of the contract event
+func eventTopic0(metadata *bind.MetaData, name string) string {
+	topic0, err := chain.EventIDFromMetadata(metadata, name)
+	if err != nil {
+		// panic, this error is fatal
+		panic(err)
+	}
+	return topic0
 }
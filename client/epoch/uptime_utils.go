@@ -1,16 +1,29 @@
 package epoch
 
 import (
+	"bytes"
 	"crypto/ecdsa"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"flare-tlc/client/config"
 	"flare-tlc/logger"
 	"flare-tlc/utils/contracts/system"
+	"flare-tlc/utils/merkle"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/pkg/errors"
-	"math/big"
 )
 
 var (
@@ -27,25 +40,233 @@ var (
 			Type: bytes32Ty,
 		},
 	}
+	bytes20ArrayTy, _         = abi.NewType("bytes20[]", "bytes20[]", nil)
+	submitUptimeVoteArguments = abi.Arguments{
+		{ // reward epoch id
+			Type: int64Ty,
+		},
+		{ // node ids
+			Type: bytes20ArrayTy,
+		},
+	}
 )
 
-func getUptimeSignature(rewardEpochId *big.Int, privateKey *ecdsa.PrivateKey) (common.Hash, *system.IFlareSystemsManagerSignature, error) {
-	logger.Info("Signing uptime vote for epoch %v: zero hash %s", rewardEpochId, hex.EncodeToString(zeroHash[:]))
+const nodeIdPrefix = "NodeID-"
+
+// Uptime of a node in percent, node id is either NodeID-<cb58> or 0x-prefixed hex
+type nodeUptime struct {
+	NodeId string  `json:"nodeId"`
+	Uptime float64 `json:"uptime"`
+}
+
+// Uptime source file format (config.UptimeSourceJSON)
+type nodeUptimeFile struct {
+	RewardEpochId *int64       `json:"rewardEpochId"` // required, must match the voted reward epoch
+	Nodes         []nodeUptime `json:"nodes"`
+}
+
+// Uptime vote for a reward epoch: ids of nodes with sufficient uptime and the merkle root
+// of the tree with keccak256 hashes of the (32 byte left padded) node ids as leaves
+type uptimeVote struct {
+	nodeIds [][20]byte
+	hash    common.Hash
+}
+
+// Computes the uptime vote from the configured source, zero hash is voted if no source is set
+func getUptimeVote(rewardEpochId *big.Int, cfg *config.UptimeConfig) (*uptimeVote, error) {
+	if cfg.Source == "" {
+		return &uptimeVote{hash: zeroHash}, nil
+	}
+
+	var nodes []nodeUptime
+	var err error
+	switch cfg.SourceType {
+	case config.UptimeSourceValidators:
+		nodes, err = fetchValidatorUptimes(cfg.Source)
+	default:
+		nodes, err = fetchNodeUptimeFile(cfg.Source, rewardEpochId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeIds [][20]byte
+	for _, node := range nodes {
+		if node.Uptime < cfg.MinUptime {
+			continue
+		}
+		nodeId, err := parseNodeId(node.NodeId)
+		if err != nil {
+			return nil, err
+		}
+		nodeIds = append(nodeIds, nodeId)
+	}
+	vote := newUptimeVote(nodeIds)
+	logger.Info("Uptime vote for epoch %v: %d of %d nodes with uptime >= %v%%, hash %v",
+		rewardEpochId, len(vote.nodeIds), len(nodes), cfg.MinUptime, vote.hash)
+	return vote, nil
+}
+
+// Builds the uptime vote from node ids, duplicates are removed and ids are sorted
+func newUptimeVote(nodeIds [][20]byte) *uptimeVote {
+	sort.Slice(nodeIds, func(i, j int) bool { return bytes.Compare(nodeIds[i][:], nodeIds[j][:]) < 0 })
+	var unique [][20]byte
+	for i, nodeId := range nodeIds {
+		if i == 0 || nodeId != nodeIds[i-1] {
+			unique = append(unique, nodeId)
+		}
+	}
+	if len(unique) == 0 {
+		return &uptimeVote{hash: zeroHash}
+	}
+
+	leaves := make([]common.Hash, len(unique))
+	for i, nodeId := range unique {
+		leaves[i] = common.BytesToHash(nodeId[:])
+	}
+	root, _ := merkle.Build(leaves, true).Root() // not empty
+	return &uptimeVote{nodeIds: unique, hash: root}
+}
+
+func fetchNodeUptimeFile(path string, rewardEpochId *big.Int) ([]nodeUptime, error) {
+	data, err := fetchData(path, "node uptime")
+	if err != nil {
+		return nil, err
+	}
+	var file nodeUptimeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errors.Wrap(err, "error decoding node uptime file")
+	}
+	if file.RewardEpochId == nil {
+		return nil, errors.New("node uptime file epoch id is missing")
+	}
+	if *file.RewardEpochId != rewardEpochId.Int64() {
+		return nil, errors.Errorf("invalid node uptime file epoch id: %d, expected: %d", *file.RewardEpochId, rewardEpochId)
+	}
+	return file.Nodes, nil
+}
+
+// Fetches uptimes of current validators from the P-chain API of a validator node,
+// e.g. http://localhost:9650/ext/bc/P. The API returns uptimes over the validators' current
+// staking periods, not over the reward epoch, so the vote is only an approximation: it is read
+// when voting starts and includes nodes whose staking period does not cover the whole epoch.
+func fetchValidatorUptimes(url string) ([]nodeUptime, error) {
+	request := []byte(`{"jsonrpc":"2.0","id":1,"method":"platform.getCurrentValidators","params":{}}`)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(request))
+	if err != nil {
+		return nil, errors.Wrap(err, "error fetching validator uptimes")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading validator uptimes")
+	}
+
+	var response struct {
+		Result struct {
+			Validators []struct {
+				NodeId string `json:"nodeID"`
+				Uptime string `json:"uptime"`
+			} `json:"validators"`
+		} `json:"result"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, errors.Wrap(err, "error decoding validator uptimes")
+	}
+	if response.Error != nil {
+		return nil, errors.Errorf("error fetching validator uptimes: %s", response.Error.Message)
+	}
+
+	nodes := make([]nodeUptime, 0, len(response.Result.Validators))
+	for _, v := range response.Result.Validators {
+		uptime, err := strconv.ParseFloat(v.Uptime, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid uptime %s of node %s", v.Uptime, v.NodeId)
+		}
+		nodes = append(nodes, nodeUptime{NodeId: v.NodeId, Uptime: uptime})
+	}
+	return nodes, nil
+}
+
+// Parses node id in NodeID-<cb58> or 0x-prefixed hex format
+func parseNodeId(s string) ([20]byte, error) {
+	var nodeId [20]byte
+	var b []byte
+	var err error
+	if strings.HasPrefix(s, nodeIdPrefix) {
+		b, err = decodeCB58(strings.TrimPrefix(s, nodeIdPrefix))
+	} else {
+		b, err = hexutil.Decode(s)
+	}
+	if err != nil {
+		return nodeId, errors.Wrapf(err, "invalid node id %s", s)
+	}
+	if len(b) != len(nodeId) {
+		return nodeId, errors.Errorf("invalid node id %s: length %d", s, len(b))
+	}
+	copy(nodeId[:], b)
+	return nodeId, nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
 
-	toSign, err := uptimeVoteArguments.Pack(rewardEpochId.Int64(), zeroHash)
+// Decodes base58 data with a 4 byte sha256 checksum suffix
+func decodeCB58(s string) ([]byte, error) {
+	n := new(big.Int)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, big.NewInt(58))
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	decoded := n.Bytes()
+	for i := 0; i < len(s) && s[i] == base58Alphabet[0]; i++ {
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) < 4 {
+		return nil, errors.New("cb58 data too short")
+	}
+	data, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	hash := sha256.Sum256(data)
+	if !bytes.Equal(hash[len(hash)-4:], checksum) {
+		return nil, errors.New("invalid cb58 checksum")
+	}
+	return data, nil
+}
+
+func getUptimeSignature(rewardEpochId *big.Int, hash common.Hash, privateKey *ecdsa.PrivateKey) (*system.IFlareSystemsManagerSignature, error) {
+	logger.Info("Signing uptime vote for epoch %v: hash %s", rewardEpochId, hex.EncodeToString(hash[:]))
+
+	toSign, err := uptimeVoteArguments.Pack(rewardEpochId.Int64(), hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error packing uptime vote arguments: %v, %v", rewardEpochId, hash)
+	}
+	return signMessage(toSign, privateKey)
+}
+
+func getSubmitUptimeVoteSignature(rewardEpochId *big.Int, nodeIds [][20]byte, privateKey *ecdsa.PrivateKey) (*system.IFlareSystemsManagerSignature, error) {
+	toSign, err := submitUptimeVoteArguments.Pack(rewardEpochId.Int64(), nodeIds)
 	if err != nil {
-		return zeroHash, nil, errors.Wrapf(err, "error packing uptime vote arguments: %v, %v", rewardEpochId, zeroHash)
+		return nil, errors.Wrapf(err, "error packing submit uptime vote arguments: %v", rewardEpochId)
 	}
+	return signMessage(toSign, privateKey)
+}
 
-	hashSignature, err := crypto.Sign(accounts.TextHash(crypto.Keccak256(toSign)), privateKey)
+// Signs keccak256 hash of the message as an Ethereum signed message
+func signMessage(message []byte, privateKey *ecdsa.PrivateKey) (*system.IFlareSystemsManagerSignature, error) {
+	hashSignature, err := crypto.Sign(accounts.TextHash(crypto.Keccak256(message)), privateKey)
 	if err != nil {
-		return zeroHash, nil, err
+		return nil, err
 	}
 
-	signature := system.IFlareSystemsManagerSignature{
+	return &system.IFlareSystemsManagerSignature{
 		R: [32]byte(hashSignature[0:32]),
 		S: [32]byte(hashSignature[32:64]),
 		V: hashSignature[64] + 27,
-	}
-	return zeroHash, &signature, nil
+	}, nil
 }
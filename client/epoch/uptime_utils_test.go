@@ -0,0 +1,72 @@
+package epoch
+
+import (
+	"flare-tlc/client/config"
+	"flare-tlc/utils/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseNodeId(t *testing.T) {
+	expected := hexutil.MustDecode("0x0102030405060708090a0b0c0d0e0f1011121314")
+
+	nodeId, err := parseNodeId("NodeID-6L5yRNPTuciSgXGHqYwn9N6NeoKMvqvy")
+	require.NoError(t, err)
+	require.Equal(t, expected, nodeId[:])
+
+	nodeId, err = parseNodeId("0x0102030405060708090a0b0c0d0e0f1011121314")
+	require.NoError(t, err)
+	require.Equal(t, expected, nodeId[:])
+
+	_, err = parseNodeId("NodeID-6L5yRNPTuciSgXGHqYwn9N6NeoKMvqvz")
+	require.Error(t, err, "invalid checksum")
+	_, err = parseNodeId("0x0102")
+	require.Error(t, err, "invalid length")
+}
+
+func TestNewUptimeVote(t *testing.T) {
+	require.Equal(t, zeroHash, newUptimeVote(nil).hash)
+
+	first := [20]byte{1}
+	second := [20]byte{2}
+	vote := newUptimeVote([][20]byte{second, first, second})
+	require.Equal(t, [][20]byte{first, second}, vote.nodeIds)
+
+	expected := merkle.SortedHashPair(
+		crypto.Keccak256Hash(common.BytesToHash(first[:]).Bytes()),
+		crypto.Keccak256Hash(common.BytesToHash(second[:]).Bytes()),
+	)
+	require.Equal(t, expected, vote.hash)
+}
+
+func TestGetUptimeVoteFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uptime.json")
+	data := `{"rewardEpochId": 5, "nodes": [
+		{"nodeId": "NodeID-6L5yRNPTuciSgXGHqYwn9N6NeoKMvqvy", "uptime": 99.5},
+		{"nodeId": "0x0000000000000000000000000000000000000001", "uptime": 50}
+	]}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	cfg := &config.UptimeConfig{Source: path, SourceType: config.UptimeSourceJSON, MinUptime: 80}
+
+	vote, err := getUptimeVote(big.NewInt(5), cfg)
+	require.NoError(t, err)
+	require.Len(t, vote.nodeIds, 1)
+	require.Equal(t, crypto.Keccak256Hash(common.BytesToHash(vote.nodeIds[0][:]).Bytes()), vote.hash)
+
+	_, err = getUptimeVote(big.NewInt(6), cfg)
+	require.Error(t, err, "epoch mismatch")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"nodes": []}`), 0644))
+	_, err = getUptimeVote(big.NewInt(5), cfg)
+	require.Error(t, err, "epoch missing")
+
+	vote, err = getUptimeVote(big.NewInt(6), &config.UptimeConfig{})
+	require.NoError(t, err)
+	require.Equal(t, zeroHash, vote.hash)
+}
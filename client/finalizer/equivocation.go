@@ -0,0 +1,44 @@
+package finalizer
+
+import (
+	"flare-tlc/client/shared"
+	"flare-tlc/logger"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const equivocationsAPIPath = "finalizer/equivocations"
+
+var equivocationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "finalizer",
+	Name:      "equivocations_total",
+	Help:      "Number of voting rounds in which a voter signed conflicting messages for the same protocol",
+}, []string{"protocol", "voter"})
+
+// A voter signed more than one message hash for the same protocol and voting round
+type equivocation struct {
+	RewardEpochId int64          `json:"rewardEpochId"`
+	VotingRoundId uint32         `json:"votingRoundId"`
+	ProtocolId    byte           `json:"protocolId"`
+	Voter         common.Address `json:"voter"`
+	MessageHashes []common.Hash  `json:"messageHashes"`
+	Timestamp     time.Time      `json:"timestamp"`
+}
+
+func reportEquivocation(e *equivocation) {
+	logger.Warn("Voter %v signed conflicting messages for protocol %d in voting round %d: %v",
+		e.Voter, e.ProtocolId, e.VotingRoundId, e.MessageHashes)
+	equivocationsTotal.WithLabelValues(strconv.Itoa(int(e.ProtocolId)), e.Voter.Hex()).Inc()
+}
+
+// Registers handler for /api/finalizer/equivocations
+func registerEquivocationsAPIHandler(s *submissionStorage) {
+	shared.RegisterAPIHandler(equivocationsAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		shared.WriteJSONResponse(w, s.Equivocations())
+	})
+}
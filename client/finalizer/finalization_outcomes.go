@@ -0,0 +1,222 @@
+package finalizer
+
+import (
+	"flare-tlc/client/shared"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	outcomesAPIPath = "finalizer/outcomes"
+	summaryAPIPath  = "finalizer/summary"
+)
+
+var (
+	finalizationAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "finalizer",
+		Name:      "attempts_total",
+		Help:      "Number of finalization attempts by chain, selection and result (relayed, relayed_by_others, failed)",
+	}, []string{"chain", "selected", "result"})
+	finalizationGasUsed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "finalizer",
+		Name:      "gas_used_total",
+		Help:      "Gas used by relay transactions by chain",
+	}, []string{"chain"})
+	finalizationsByRewardEpoch = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "finalizer",
+		Name:      "reward_epoch_finalizations",
+		Help:      "Number of successful finalizations in the reward epoch where we were a selected finalizer",
+	}, []string{"reward_epoch"})
+)
+
+// Outcome of a single finalization attempt on one chain
+type finalizationOutcome struct {
+	RewardEpochId   int64            `json:"rewardEpochId"`
+	VotingRoundId   uint32           `json:"votingRoundId"`
+	ProtocolId      byte             `json:"protocolId"`
+	MerkleRoot      common.Hash      `json:"merkleRoot"`
+	Chain           string           `json:"chain"`
+	Signers         []common.Address `json:"signers"`
+	Selected        bool             `json:"selected"` // true if we were a selected finalizer
+	TxHash          *common.Hash     `json:"txHash,omitempty"`
+	GasUsed         uint64           `json:"gasUsed"`
+	RelayedByOthers bool             `json:"relayedByOthers"`
+	Success         bool             `json:"success"`
+	Error           string           `json:"error,omitempty"`
+	Timestamp       time.Time        `json:"timestamp"`
+}
+
+// Per reward epoch summary of finalization outcomes
+type finalizationSummary struct {
+	RewardEpochId   int64  `json:"rewardEpochId"`
+	Attempts        int    `json:"attempts"`
+	Selected        int    `json:"selected"`
+	Relayed         int    `json:"relayed"`
+	SelectedRelayed int    `json:"selectedRelayed"`
+	RelayedByOthers int    `json:"relayedByOthers"`
+	Failed          int    `json:"failed"`
+	GasUsed         uint64 `json:"gasUsed"`
+}
+
+// Stores finalization outcomes by reward epoch
+type finalizationTracker struct {
+	outcomes map[int64][]*finalizationOutcome
+
+	sync.RWMutex
+}
+
+func newFinalizationTracker() *finalizationTracker {
+	return &finalizationTracker{
+		outcomes: make(map[int64][]*finalizationOutcome),
+	}
+}
+
+func (t *finalizationTracker) Record(o *finalizationOutcome) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.outcomes[o.RewardEpochId] = append(t.outcomes[o.RewardEpochId], o)
+
+	selected := strconv.FormatBool(o.Selected)
+	switch {
+	case o.RelayedByOthers:
+		finalizationAttempts.WithLabelValues(o.Chain, selected, "relayed_by_others").Inc()
+	case o.Success:
+		finalizationAttempts.WithLabelValues(o.Chain, selected, "relayed").Inc()
+	default:
+		finalizationAttempts.WithLabelValues(o.Chain, selected, "failed").Inc()
+	}
+	finalizationGasUsed.WithLabelValues(o.Chain).Add(float64(o.GasUsed))
+	if o.Selected && o.Success && !o.RelayedByOthers {
+		finalizationsByRewardEpoch.WithLabelValues(strconv.FormatInt(o.RewardEpochId, 10)).Inc()
+	}
+}
+
+// Returns outcomes for the reward epoch, ordered by voting round
+func (t *finalizationTracker) Outcomes(rewardEpochId int64) []finalizationOutcome {
+	t.RLock()
+	defer t.RUnlock()
+
+	result := make([]finalizationOutcome, 0, len(t.outcomes[rewardEpochId]))
+	for _, o := range t.outcomes[rewardEpochId] {
+		result = append(result, *o)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].VotingRoundId < result[j].VotingRoundId
+	})
+	return result
+}
+
+// Returns summaries for all stored reward epochs, ordered by reward epoch id
+func (t *finalizationTracker) Summary() []finalizationSummary {
+	t.RLock()
+	defer t.RUnlock()
+
+	result := make([]finalizationSummary, 0, len(t.outcomes))
+	for rewardEpochId, outcomes := range t.outcomes {
+		summary := finalizationSummary{RewardEpochId: rewardEpochId}
+		for _, o := range outcomes {
+			summary.Attempts++
+			summary.GasUsed += o.GasUsed
+			if o.Selected {
+				summary.Selected++
+			}
+			switch {
+			case o.RelayedByOthers:
+				summary.RelayedByOthers++
+			case o.Success:
+				summary.Relayed++
+				if o.Selected {
+					summary.SelectedRelayed++
+				}
+			default:
+				summary.Failed++
+			}
+		}
+		result = append(result, summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].RewardEpochId < result[j].RewardEpochId
+	})
+	return result
+}
+
+func (t *finalizationTracker) RemoveRewardEpochs(rewardEpochIds []uint32) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, id := range rewardEpochIds {
+		delete(t.outcomes, int64(id))
+		finalizationsByRewardEpoch.DeleteLabelValues(strconv.FormatInt(int64(id), 10))
+	}
+}
+
+// Registers finalizer API handlers:
+//   - /api/finalizer/outcomes?rewardEpochId=<id>
+//   - /api/finalizer/summary
+func (t *finalizationTracker) RegisterAPIHandlers() {
+	shared.RegisterAPIHandler(outcomesAPIPath, t.outcomesHandler)
+	shared.RegisterAPIHandler(summaryAPIPath, t.summaryHandler)
+}
+
+func (t *finalizationTracker) outcomesHandler(w http.ResponseWriter, r *http.Request) {
+	rewardEpochId, err := strconv.ParseInt(r.URL.Query().Get("rewardEpochId"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid or missing rewardEpochId parameter", http.StatusBadRequest)
+		return
+	}
+	shared.WriteJSONResponse(w, t.Outcomes(rewardEpochId))
+}
+
+func (t *finalizationTracker) summaryHandler(w http.ResponseWriter, r *http.Request) {
+	shared.WriteJSONResponse(w, t.Summary())
+}
+
+func newFinalizationOutcome(
+	item *queueItem, data *messageData, signers []*signedPayload, chainName string, selected bool,
+) *finalizationOutcome {
+	o := &finalizationOutcome{
+		VotingRoundId: item.votingRoundId,
+		ProtocolId:    item.protocolId,
+		Chain:         chainName,
+		Selected:      selected,
+		Timestamp:     time.Now(),
+	}
+	if data != nil && data.signingPolicy != nil {
+		o.RewardEpochId = data.signingPolicy.rewardEpochId
+	}
+	for _, payload := range signers {
+		o.Signers = append(o.Signers, payload.signer)
+	}
+	if len(signers) > 0 {
+		o.MerkleRoot = common.BytesToHash(signers[0].message.merkleRoot)
+	} else if data != nil {
+		for _, payload := range data.payload {
+			if payload != nil {
+				o.MerkleRoot = common.BytesToHash(payload.message.merkleRoot)
+				break
+			}
+		}
+	}
+	return o
+}
+
+func (o *finalizationOutcome) setResult(result relayResult) {
+	o.Success = result.success
+	o.RelayedByOthers = result.relayedByOthers
+	if result.receipt != nil {
+		txHash := result.receipt.TxHash
+		o.TxHash = &txHash
+		o.GasUsed = result.receipt.GasUsed
+	}
+	if result.err != nil {
+		o.Error = result.err.Error()
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"flare-tlc/config"
 	"flare-tlc/database"
 	"flare-tlc/logger"
+	"flare-tlc/utils"
 	"flare-tlc/utils/contracts/relay"
 	"flare-tlc/utils/credentials"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -18,6 +20,8 @@ import (
 	"gorm.io/gorm"
 )
 
+const signingPolicyBackfillInterval = 30 * time.Second
+
 type finalizerClient struct {
 	db finalizerDB
 
@@ -26,6 +30,7 @@ type finalizerClient struct {
 	signingPolicyStorage *signingPolicyStorage
 	submissionStorage    *submissionStorage
 	queueProcessor       *finalizerQueueProcessor
+	tracker              *finalizationTracker
 
 	finalizerContext *finalizerContext
 }
@@ -93,18 +98,40 @@ func NewFinalizerClient(ctx clientContext.ClientContext) (*finalizerClient, erro
 	if err != nil {
 		return nil, err
 	}
+	relayClient.SetLegacyAddresses(cfg.ContractAddresses.LegacyRelays, finalizerContext.rewardEpochTiming())
+	var relayTargets []*relayContractClient
+	for i := range cfg.Finalizer.RelayTargets {
+		target, err := NewRelayTargetClient(&cfg.Finalizer.RelayTargets[i])
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Finalizer will also relay to chain %s, relay contract %v", target.chainName, target.address)
+		relayTargets = append(relayTargets, target)
+	}
 	submissionClient := NewSubmissionContractClient(cfg.ContractAddresses.Submission)
-	submissionStorage := newSubmissionStorage()
+	submissionClient.SetLegacyAddresses(cfg.ContractAddresses.LegacySubmissions, finalizerContext.rewardEpochTiming())
+	submissionStorage := newSubmissionStorage(cfg.Finalizer.ExcludeEquivocators)
+	registerEquivocationsAPIHandler(submissionStorage)
+	registerSignatureStatsAPIHandler(submissionStorage, finalizerContext.votingEpoch)
+	tracker := newFinalizationTracker()
+	tracker.RegisterAPIHandlers()
 
 	db := finalizerDBImpl{client: ctx.DB()}
+	queueProcessor := newFinalizerQueueProcessor(db, submissionStorage, relayClient, relayTargets, finalizerContext, tracker)
+	if finalizerContext.shadowMode {
+		logger.Info("Finalizer runs in shadow mode, no relay transactions will be sent")
+		queueProcessor.shadowTracker.RegisterAPIHandlers()
+	}
+	queueProcessor.timeline.RegisterAPIHandlers()
 
 	return &finalizerClient{
 		db:                   db,
 		relayClient:          relayClient,
-		signingPolicyStorage: newSigningPolicyStorage(),
+		signingPolicyStorage: newSigningPolicyStorage(finalizerContext.rewardEpoch),
 		submissionStorage:    submissionStorage,
 		submissionClient:     submissionClient,
-		queueProcessor:       newFinalizerQueueProcessor(db, submissionStorage, relayClient, finalizerContext),
+		queueProcessor:       queueProcessor,
+		tracker:              tracker,
 		finalizerContext:     finalizerContext,
 	}, nil
 }
@@ -116,8 +143,13 @@ func (c *finalizerClient) Run(ctx context.Context) error {
 func (c *finalizerClient) RunContext(ctx context.Context) error {
 	eg, ctx := errgroup.WithContext(ctx)
 
+	err := c.finalizerContext.thresholdFallback.LoadRelayRules(ctx, c.relayClient)
+	if err != nil {
+		return err
+	}
+
 	startTime := time.Now().Add(-c.finalizerContext.startTimeOffset)
-	startTime, err := c.fetchExistingSigningPolicies(ctx, startTime)
+	startTime, err = c.fetchExistingSigningPolicies(ctx, startTime)
 	if err != nil {
 		return err
 	}
@@ -125,12 +157,18 @@ func (c *finalizerClient) RunContext(ctx context.Context) error {
 	eg.Go(func() error {
 		return c.runSigningPolicyInitializedListener(ctx, startTime)
 	})
+	eg.Go(func() error {
+		return c.runSigningPolicyBackfill(ctx)
+	})
 	eg.Go(func() error {
 		return c.submissionClient.SubmissionTxListener(ctx, c.db, startTime, c)
 	})
 	eg.Go(func() error {
 		return c.queueProcessor.Run(ctx)
 	})
+	eg.Go(func() error {
+		return c.runProtocolMessageRelayedListener(ctx, time.Now())
+	})
 
 	return eg.Wait()
 }
@@ -162,7 +200,7 @@ func (c *finalizerClient) fetchExistingSigningPolicies(
 }
 
 func (c *finalizerClient) runSigningPolicyInitializedListener(ctx context.Context, startTime time.Time) error {
-	spListener := c.relayClient.SigningPolicyInitializedListener(c.db, startTime)
+	spListener := c.relayClient.SigningPolicyInitializedListener(ctx, c.db, startTime)
 	for {
 		var dbPolicy signingPolicyListenerResponse
 		select {
@@ -182,10 +220,112 @@ func (c *finalizerClient) runSigningPolicyInitializedListener(ctx context.Contex
 			logger.Warn("Error adding signing policy %v", err)
 		}
 		logger.Info("New signing policy received for epoch %v", policy.rewardEpochId)
+		for _, gap := range c.signingPolicyStorage.Gaps() {
+			logger.Warn("Missing signing policies for reward epochs %d-%d", gap.fromRewardEpochId, gap.toRewardEpochId)
+		}
+		// Signing policy for the next reward epoch is initialized during the current one,
+		// so all signatures of the previous reward epoch have been collected
+		c.updateSignatureStatsMetrics(policy.rewardEpochId - 2)
 		c.rewardEpochCleanup()
 	}
 }
 
+// Periodically fetches signing policies missing between stored ones, first from the
+// database and then from the chain if the indexer does not have them
+func (c *finalizerClient) runSigningPolicyBackfill(ctx context.Context) error {
+	ticker := time.NewTicker(signingPolicyBackfillInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			break
+
+		case <-ctx.Done():
+			logger.Info("Signing policy backfill stopped")
+			return ctx.Err()
+		}
+
+		for _, gap := range c.signingPolicyStorage.Gaps() {
+			c.backfillSigningPolicies(ctx, gap)
+		}
+	}
+}
+
+func (c *finalizerClient) backfillSigningPolicies(ctx context.Context, gap signingPolicyGap) {
+	logger.Info("Backfilling signing policies for reward epochs %d-%d", gap.fromRewardEpochId, gap.toRewardEpochId)
+
+	added := make(map[int64]*signingPolicy)
+	spList, err := c.relayClient.FetchSigningPolicies(c.db, int64(gap.fromTimestamp), int64(gap.toTimestamp))
+	if err != nil {
+		logger.Error("Error fetching signing policies from the database: %v", err)
+	}
+	for _, sp := range spList {
+		policy := newSigningPolicy(sp.policyData)
+		if policy.rewardEpochId < gap.fromRewardEpochId || policy.rewardEpochId > gap.toRewardEpochId {
+			continue
+		}
+		if err := c.signingPolicyStorage.Add(policy); err != nil {
+			logger.Warn("Error adding signing policy %v", err)
+			continue
+		}
+		added[policy.rewardEpochId] = policy
+	}
+
+	for rewardEpochId := gap.fromRewardEpochId; rewardEpochId <= gap.toRewardEpochId; rewardEpochId++ {
+		if added[rewardEpochId] != nil {
+			continue
+		}
+		policyData, err := c.relayClient.FetchSigningPolicyFromChain(ctx, rewardEpochId, gap.fromTimestamp, gap.toTimestamp)
+		if err != nil {
+			logger.Error("Error fetching signing policy for reward epoch %d from the chain: %v", rewardEpochId, err)
+			continue
+		}
+		if policyData == nil {
+			logger.Warn("Signing policy for reward epoch %d not found", rewardEpochId)
+			continue
+		}
+		policy := newSigningPolicy(policyData)
+		if err := c.signingPolicyStorage.Add(policy); err != nil {
+			logger.Warn("Error adding signing policy %v", err)
+			continue
+		}
+		added[rewardEpochId] = policy
+	}
+	if len(added) == 0 {
+		return
+	}
+	logger.Info("Backfilled %d signing policies", len(added))
+
+	// Submissions for voting rounds of the backfilled policies were skipped, read them again
+	var startVotingRoundId uint32 = math.MaxUint32
+	for _, policy := range added {
+		startVotingRoundId = utils.Min(startVotingRoundId, policy.startVotingRoundId)
+	}
+	from := c.finalizerContext.votingEpoch.StartTime(int64(startVotingRoundId)).Unix()
+	err = c.submissionClient.ReprocessSubmissions(c.db, from, time.Now().Unix(), c)
+	if err != nil {
+		logger.Error("Error reprocessing submissions from voting round %d: %v", startVotingRoundId, err)
+	}
+}
+
+// Records ProtocolMessageRelayed events on the default chain in finalization timelines
+func (c *finalizerClient) runProtocolMessageRelayedListener(ctx context.Context, startTime time.Time) error {
+	relayedListener := c.relayClient.ProtocolMessageRelayedListener(ctx, c.db, startTime)
+	for {
+		var relayed protocolMessageRelayed
+		select {
+		case relayed = <-relayedListener:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		sp, _ := c.signingPolicyStorage.GetForVotingRound(relayed.key.votingRoundId)
+		if sp == nil {
+			continue
+		}
+		c.queueProcessor.timeline.MessageRelayed(sp.rewardEpochId, relayed.key, time.Unix(relayed.timestamp, 0))
+	}
+}
+
 func (c *finalizerClient) ProcessSubmissionData(slr submissionListenerResponse) error {
 	for _, payloadItem := range slr.payload {
 		if payloadItem.votingRoundId < c.finalizerContext.startingVotingRound {
@@ -199,6 +339,11 @@ func (c *finalizerClient) ProcessSubmissionData(slr submissionListenerResponse)
 		}
 		sp, threshold := c.signingPolicyData(payloadItem.votingRoundId)
 		if sp == nil {
+			if c.signingPolicyStorage.InGap(payloadItem.votingRoundId) {
+				// Signing policy is being backfilled, do not block processing of other voting rounds
+				logger.Debug("Ignoring submitted signature for voting round %d - signing policy missing", payloadItem.votingRoundId)
+				continue
+			}
 			first := c.signingPolicyStorage.First()
 			if first != nil && payloadItem.votingRoundId < first.startVotingRoundId {
 				// This is a submission for an old voting round, skip it
@@ -207,14 +352,25 @@ func (c *finalizerClient) ProcessSubmissionData(slr submissionListenerResponse)
 			}
 			return fmt.Errorf("no signing policy found for voting round %d", payloadItem.votingRoundId)
 		}
+		payloadItem.payload.timestamp = slr.timestamp
 		addResult, err := c.submissionStorage.Add(payloadItem.payload, sp, threshold)
 		if err != nil {
 			// Error is non-fatal, skip this submission
 			logger.Debug("Ignoring submitted signature: %v", err)
 			continue
 		}
+		key := relayKey{protocolId: payloadItem.protocolId, votingRoundId: payloadItem.votingRoundId}
+		c.queueProcessor.timeline.SignatureSeen(sp.rewardEpochId, key, time.Unix(slr.timestamp, 0))
+		if addResult.equivocation != nil {
+			reportEquivocation(addResult.equivocation)
+		}
 		if addResult.thresholdReached {
 			logger.Info("Threshold reached for protocol %d in voting round %d with hash %v", payloadItem.protocolId, payloadItem.votingRoundId, payloadItem.payload.messageHash)
+			c.queueProcessor.timeline.ThresholdReached(sp.rewardEpochId, key, time.Unix(slr.timestamp, 0))
+			if !c.finalizerContext.isProtocolEnabled(payloadItem.protocolId) {
+				logger.Debug("Finalization of protocol %d is disabled, skipping", payloadItem.protocolId)
+				continue
+			}
 			c.queueProcessor.Add(payloadItem, sp.seed)
 		}
 	}
@@ -227,17 +383,7 @@ func (c *finalizerClient) signingPolicyData(votingRoundId uint32) (*signingPolic
 	if sp == nil {
 		return nil, 0
 	}
-	if !last {
-		return sp, sp.threshold
-	}
-	endVotingEpoch := c.finalizerContext.rewardEpoch.EndEpoch(sp.rewardEpochId)
-	end := c.finalizerContext.votingEpoch.EndTime(endVotingEpoch)
-
-	if time.Now().Before(end) {
-		return sp, sp.threshold
-	} else {
-		return sp, uint16((uint32(sp.voters.TotalWeight()) * 60) / 100)
-	}
+	return sp, c.finalizerContext.thresholdFallback.threshold(sp, votingRoundId, last)
 }
 
 // Return true if voting round is not in the future, i.e., is <= the current voting round
@@ -246,6 +392,15 @@ func (c *finalizerClient) checkVotingRoundTime(votingRoundId uint32) bool {
 	return votingRoundId <= uint32(currentEpochId)
 }
 
+func (c *finalizerClient) updateSignatureStatsMetrics(rewardEpochId int64) {
+	if rewardEpochId < c.finalizerContext.startingRewardEpoch {
+		return
+	}
+	stats := c.submissionStorage.SignatureStats(rewardEpochId, c.finalizerContext.votingEpoch)
+	setSignatureStatsMetrics(stats)
+	logger.Info("Updated signature statistics metrics for reward epoch %d", rewardEpochId)
+}
+
 func (c *finalizerClient) rewardEpochCleanup() {
 	cleanupTime := time.Now().Add(-2 * c.finalizerContext.startTimeOffset)
 	cleanupVotingRoundId := c.finalizerContext.votingEpoch.EpochIndex(cleanupTime)
@@ -254,6 +409,9 @@ func (c *finalizerClient) rewardEpochCleanup() {
 	}
 	removedEpochIds := c.signingPolicyStorage.RemoveByVotingRound(uint32(cleanupVotingRoundId))
 	c.submissionStorage.RemoveVotingRoundIds(removedEpochIds)
+	c.tracker.RemoveRewardEpochs(removedEpochIds)
+	c.queueProcessor.shadowTracker.RemoveRewardEpochs(removedEpochIds)
+	c.queueProcessor.timeline.RemoveRewardEpochs(removedEpochIds)
 	if len(removedEpochIds) > 0 {
 		logger.Info("Removed signing policies and submissions with reward epoch <= %d", removedEpochIds[len(removedEpochIds)-1])
 	}
@@ -6,6 +6,8 @@ import (
 	"crypto/ecdsa"
 	"encoding/binary"
 	"encoding/hex"
+	clientConfig "flare-tlc/client/config"
+	"flare-tlc/client/shared"
 	"flare-tlc/config"
 	"flare-tlc/database"
 	"flare-tlc/logger"
@@ -73,6 +75,70 @@ func TestFinalizerClientMainline(t *testing.T) {
 	t.Logf("sent transactions: %d", len(clients.eth.sentTxs))
 	require.Len(t, clients.eth.sentTxs, 1)
 	cupaloy.SnapshotT(t, clients.eth.sentTxs[0])
+
+	outcomes := clients.finalizer.tracker.Outcomes(1)
+	require.Len(t, outcomes, 1)
+	require.True(t, outcomes[0].Success)
+	require.True(t, outcomes[0].Selected)
+	require.Equal(t, uint64(100000), outcomes[0].GasUsed)
+	require.Equal(t, common.BytesToHash(bytes.Repeat([]byte{0xff}, 32)), outcomes[0].MerkleRoot)
+	require.Equal(t, []finalizationSummary{{
+		RewardEpochId: 1, Attempts: 1, Selected: 1, Relayed: 1, SelectedRelayed: 1, GasUsed: 100000,
+	}}, clients.finalizer.tracker.Summary())
+
+	timelines := clients.finalizer.queueProcessor.timeline.Timelines(func(*roundTimeline) bool { return true })
+	require.Len(t, timelines, 1)
+	require.Equal(t, int64(1), timelines[0].RewardEpochId)
+	require.True(t, timelines[0].Selected)
+	for _, ts := range []*time.Time{
+		timelines[0].FirstSignature, timelines[0].ThresholdReached, timelines[0].SelectionDecision,
+		timelines[0].TxSent, timelines[0].TxMined,
+	} {
+		require.NotNil(t, ts)
+	}
+	require.False(t, timelines[0].TxMined.Before(*timelines[0].TxSent))
+}
+
+func TestFinalizerClientRelayTargets(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	clients, err := setupTest()
+	require.NoError(t, err)
+
+	privateKey, err := crypto.HexToECDSA(testPrivateKeyHex)
+	require.NoError(t, err)
+
+	targetEth := new(testEthClient)
+	targetRelayAddress := common.HexToAddress("0x5a0773ff307bf7c71a832dbb5312237fd3437f9f")
+	target, err := NewRelayContractClient(
+		nil, targetRelayAddress, privateKey, crypto.PubkeyToAddress(privateKey.PublicKey),
+	)
+	require.NoError(t, err)
+	target.chainName = "target"
+	target.ethClient = targetEth
+	clients.finalizer.queueProcessor.relayTargets = []*relayContractClient{target}
+
+	eg, ctx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		return clients.finalizer.RunContext(ctx)
+	})
+
+	require.Eventually(
+		t, func() bool { return clients.eth.hasAnyCalls() && targetEth.hasAnyCalls() },
+		10*time.Second, 100*time.Millisecond,
+	)
+
+	cancel()
+	err = eg.Wait()
+	require.True(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+
+	require.Len(t, clients.eth.sentTxs, 1)
+	require.Len(t, targetEth.sentTxs, 1)
+	require.Equal(t, relayContractAddress, clients.eth.sentTxs[0].to)
+	require.Equal(t, targetRelayAddress, targetEth.sentTxs[0].to)
+	require.Equal(t, clients.eth.sentTxs[0].data, targetEth.sentTxs[0].data)
 }
 
 func TestFinalizerClientSendTxErr(t *testing.T) {
@@ -149,6 +215,55 @@ func TestFinalizerClientFetchLogssErr(t *testing.T) {
 	require.Empty(t, clients.eth.sentTxs)
 }
 
+func TestReprocessSubmissions(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKeyHex)
+	require.NoError(t, err)
+	db, err := newTestDB(privateKey)
+	require.NoError(t, err)
+
+	processor := &testSubmissionProcessor{}
+	client := NewSubmissionContractClient(submissionContractAddress)
+	err = client.ReprocessSubmissions(db, 0, time.Now().Unix(), processor)
+	require.NoError(t, err)
+	require.Len(t, processor.responses, 1)
+	require.NotEmpty(t, processor.responses[0].payload)
+}
+
+func TestShadowProcessItemAlreadyRelayed(t *testing.T) {
+	clients, err := setupTest()
+	require.NoError(t, err)
+
+	policyData, err := shared.ParseSigningPolicyInitializedEvent(clients.finalizer.relayClient.relay, *clients.db.spiLog)
+	require.NoError(t, err)
+	require.NoError(t, clients.finalizer.signingPolicyStorage.Add(newSigningPolicy(policyData)))
+
+	payload, err := DecodeSubmitterPayload(clients.db.submitterPayload)
+	require.NoError(t, err)
+	require.NoError(t, clients.finalizer.ProcessSubmissionData(submissionListenerResponse{payload: payload, timestamp: 1}))
+
+	// another finalizer relayed the message, the pre-flight call reverts
+	clients.eth.callErr = errors.New("execution reverted: Already relayed")
+	item := &queueItem{votingRoundId: 1, protocolId: 1, messageHash: payload[0].payload.messageHash}
+	processor := clients.finalizer.queueProcessor
+	processor.shadowProcessItem(context.Background(), item, true)
+
+	root := common.BytesToHash(bytes.Repeat([]byte{0xff}, 32))
+	processor.shadowTracker.Compare(item.key(), &root)
+	results := processor.shadowTracker.Results(1)
+	require.Len(t, results, 1)
+	require.Empty(t, results[0].Error)
+	require.Equal(t, shadowResultAgree, results[0].Result)
+}
+
+type testSubmissionProcessor struct {
+	responses []submissionListenerResponse
+}
+
+func (p *testSubmissionProcessor) ProcessSubmissionData(slr submissionListenerResponse) error {
+	p.responses = append(p.responses, slr)
+	return nil
+}
+
 type testClients struct {
 	db        *testDB
 	eth       *testEthClient
@@ -177,7 +292,8 @@ func setupTest() (*testClients, error) {
 
 	relayClient.ethClient = ethClient
 
-	submissionStorage := newSubmissionStorage()
+	submissionStorage := newSubmissionStorage(false)
+	tracker := newFinalizationTracker()
 
 	db, err := newTestDB(privateKey)
 	if err != nil {
@@ -195,16 +311,20 @@ func setupTest() (*testClients, error) {
 		},
 		voterThresholdBIPS: 5000,
 	}
+	fCtx.thresholdFallback = newThresholdFallback(
+		&clientConfig.ThresholdFallbackConfig{Mode: clientConfig.ThresholdFallbackRelay}, fCtx.rewardEpoch,
+	)
 
 	client := &finalizerClient{
 		db:                   db,
 		relayClient:          relayClient,
-		signingPolicyStorage: newSigningPolicyStorage(),
+		signingPolicyStorage: newSigningPolicyStorage(nil),
 		submissionStorage:    submissionStorage,
 		submissionClient:     NewSubmissionContractClient(submissionContractAddress),
 		queueProcessor: newFinalizerQueueProcessor(
-			db, submissionStorage, relayClient, fCtx,
+			db, submissionStorage, relayClient, nil, fCtx, tracker,
 		),
+		tracker:          tracker,
 		finalizerContext: fCtx,
 	}
 
@@ -220,6 +340,8 @@ type testEthClient struct {
 	sentTxs   []*sentTxInfo
 	mu        sync.RWMutex
 	sendTxErr error
+	callErr   error
+	stateData *relayStateData
 }
 
 type sentTxInfo struct {
@@ -228,14 +350,14 @@ type sentTxInfo struct {
 	data       []byte
 }
 
-func (eth *testEthClient) SendRawTx(privateKey *ecdsa.PrivateKey, to common.Address, data []byte, dryRun bool) error {
+func (eth *testEthClient) SendRawTx(privateKey *ecdsa.PrivateKey, to common.Address, data []byte, dryRun bool) (*types.Receipt, error) {
 	eth.mu.Lock()
 	defer eth.mu.Unlock()
 
 	eth.calls++
 
 	if eth.sendTxErr != nil {
-		return eth.sendTxErr
+		return nil, eth.sendTxErr
 	}
 
 	eth.sentTxs = append(eth.sentTxs, &sentTxInfo{
@@ -244,7 +366,33 @@ func (eth *testEthClient) SendRawTx(privateKey *ecdsa.PrivateKey, to common.Addr
 		data:       data,
 	})
 
-	return nil
+	return &types.Receipt{
+		TxHash:  crypto.Keccak256Hash(data),
+		GasUsed: 100000,
+		Status:  types.ReceiptStatusSuccessful,
+	}, nil
+}
+
+func (eth *testEthClient) CallContract(ctx context.Context, from common.Address, to common.Address, data []byte) error {
+	return eth.callErr
+}
+
+func (eth *testEthClient) EstimateGas(ctx context.Context, from common.Address, to common.Address, data []byte) (uint64, error) {
+	return 100000, eth.callErr
+}
+
+func (eth *testEthClient) RelayStateData(ctx context.Context, relayAddress common.Address) (*relayStateData, error) {
+	if eth.stateData != nil {
+		return eth.stateData, nil
+	}
+	// threshold is never increased
+	return &relayStateData{thresholdIncreaseBIPS: 10000}, nil
+}
+
+func (eth *testEthClient) SigningPolicyInitialized(
+	ctx context.Context, relayAddress common.Address, rewardEpochId int64, fromTimestamp, toTimestamp uint64,
+) (*relay.RelaySigningPolicyInitialized, error) {
+	return nil, nil
 }
 
 func (eth *testEthClient) hasAnyCalls() bool {
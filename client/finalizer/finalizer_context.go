@@ -5,7 +5,11 @@ import (
 	"flare-tlc/client/shared"
 	"flare-tlc/utils"
 	"flare-tlc/utils/contracts/relay"
+	"fmt"
+	"strconv"
 	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
 )
 
 // Finalizer client settings
@@ -16,6 +20,14 @@ type finalizerContext struct {
 
 	voterThresholdBIPS   uint16
 	gracePeriodEndOffset time.Duration
+	gracePeriodJitter    time.Duration
+	workers              int
+	shadowMode           bool // no transactions are sent, see config.FinalizerModeShadow
+	thresholdFallback    *thresholdFallback
+
+	allowedProtocols mapset.Set[byte] // empty set allows all protocols
+	deniedProtocols  mapset.Set[byte]
+	protocolPriority map[byte]int
 
 	votingEpoch *utils.Epoch
 	rewardEpoch *utils.IntEpoch
@@ -27,6 +39,10 @@ func newFinalizerContext(cfg *config.ClientConfig, relay *relay.Relay) (*finaliz
 	if err != nil {
 		return nil, err
 	}
+	protocolPriority, err := parseProtocolPriority(cfg.Finalizer.ProtocolPriority)
+	if err != nil {
+		return nil, err
+	}
 	startingVotingRound := cfg.Finalizer.StartingVotingRound
 	if startingVotingRound == 0 {
 		startingVotingRound = uint32(votingEpoch.EpochIndex(time.Now()))
@@ -37,7 +53,46 @@ func newFinalizerContext(cfg *config.ClientConfig, relay *relay.Relay) (*finaliz
 		startTimeOffset:      cfg.Finalizer.StartOffset,
 		voterThresholdBIPS:   cfg.Finalizer.VoterThresholdBIPS,
 		gracePeriodEndOffset: cfg.Finalizer.GracePeriodEndOffset,
+		gracePeriodJitter:    cfg.Finalizer.GracePeriodJitter,
+		workers:              cfg.Finalizer.Workers,
+		shadowMode:           cfg.Finalizer.Mode == config.FinalizerModeShadow,
+		thresholdFallback:    newThresholdFallback(&cfg.Finalizer.ThresholdFallback, rewardEpoch),
+		allowedProtocols:     mapset.NewSet(cfg.Finalizer.AllowedProtocols...),
+		deniedProtocols:      mapset.NewSet(cfg.Finalizer.DeniedProtocols...),
+		protocolPriority:     protocolPriority,
 		votingEpoch:          votingEpoch,
 		rewardEpoch:          rewardEpoch,
 	}, nil
 }
+
+// Returns reward epoch timing, reward epochs are expected to start every rewardEpoch.Period voting rounds
+func (c *finalizerContext) rewardEpochTiming() *utils.Epoch {
+	return utils.NewEpoch(
+		c.votingEpoch.StartTime(c.rewardEpoch.Start),
+		c.votingEpoch.Period*time.Duration(c.rewardEpoch.Period),
+	)
+}
+
+func parseProtocolPriority(cfgPriority map[string]int) (map[byte]int, error) {
+	result := make(map[byte]int, len(cfgPriority))
+	for key, priority := range cfgPriority {
+		protocolId, err := strconv.ParseUint(key, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid protocol id %s in protocol_priority", key)
+		}
+		result[byte(protocolId)] = priority
+	}
+	return result, nil
+}
+
+// Return true if messages of the protocol should be finalized
+func (c *finalizerContext) isProtocolEnabled(protocolId byte) bool {
+	if c.deniedProtocols != nil && c.deniedProtocols.Contains(protocolId) {
+		return false
+	}
+	return c.allowedProtocols == nil || c.allowedProtocols.Cardinality() == 0 || c.allowedProtocols.Contains(protocolId)
+}
+
+func (c *finalizerContext) priority(protocolId byte) int {
+	return c.protocolPriority[protocolId]
+}
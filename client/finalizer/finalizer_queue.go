@@ -1,20 +1,19 @@
 package finalizer
 
 import (
+	"container/heap"
 	"context"
 	"flare-tlc/logger"
 	"flare-tlc/utils"
 	"fmt"
 	"math/big"
+	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"golang.org/x/exp/slices"
-)
-
-const (
-	finalizerQueueProcessorInterval = 100 * time.Millisecond
+	"golang.org/x/sync/errgroup"
 )
 
 type queueItem struct {
@@ -24,16 +23,66 @@ type queueItem struct {
 	messageHash   common.Hash
 }
 
+func (i *queueItem) key() relayKey {
+	return relayKey{protocolId: i.protocolId, votingRoundId: i.votingRoundId}
+}
+
 func (i *queueItem) String() string {
 	return fmt.Sprintf("seed=%v, votingRoundId=%v, protocolId=%v, messageHash=%v", i.seed, i.votingRoundId, i.protocolId, i.messageHash.Hex())
 }
 
+type prioritizedQueueItem struct {
+	item     *queueItem
+	priority int
+	sequence uint64 // insertion order, keeps the queue FIFO for items with equal priority
+}
+
+// Priority queue of items, implements heap.Interface.
+// Items with higher priority are popped first, items with equal priority
+// are ordered by voting round id and insertion order.
+type itemHeap []*prioritizedQueueItem
+
+func (h itemHeap) Len() int { return len(h) }
+
+func (h itemHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	if h[i].item.votingRoundId != h[j].item.votingRoundId {
+		return h[i].item.votingRoundId < h[j].item.votingRoundId
+	}
+	return h[i].sequence < h[j].sequence
+}
+
+func (h itemHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *itemHeap) Push(x any) { *h = append(*h, x.(*prioritizedQueueItem)) }
+
+func (h *itemHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
 type finalizerQueue struct {
-	queue []*queueItem
+	queue    itemHeap
+	sequence uint64
+
+	// signals that items were added to the queue
+	ready chan struct{}
 
 	sync.Mutex
 }
 
+// Key of the relayed message, at most one message per protocol and voting round can be relayed
+type relayKey struct {
+	protocolId    byte
+	votingRoundId uint32
+}
+
 type finalizerQueueProcessor struct {
 	db            finalizerDB
 	queue         *finalizerQueue
@@ -41,20 +90,35 @@ type finalizerQueueProcessor struct {
 
 	submissionStorage *submissionStorage
 	relayClient       *relayContractClient
+	relayTargets      []*relayContractClient // relay clients for additional chains
 	finalizerContext  *finalizerContext
+	tracker           *finalizationTracker
+	shadowTracker     *shadowTracker
+	timeline          *timelineTracker
+
+	// messages currently being relayed by one of the workers
+	inProgress   map[relayKey]bool
+	inProgressMu sync.Mutex
 }
 
 func newFinalizerQueueProcessor(
 	db finalizerDB,
 	submissionStorage *submissionStorage,
 	relayClient *relayContractClient,
+	relayTargets []*relayContractClient,
 	finalizerContext *finalizerContext,
+	tracker *finalizationTracker,
 ) *finalizerQueueProcessor {
 	qp := &finalizerQueueProcessor{
 		db:                db,
 		submissionStorage: submissionStorage,
 		relayClient:       relayClient,
+		relayTargets:      relayTargets,
+		tracker:           tracker,
+		shadowTracker:     newShadowTracker(),
+		timeline:          newTimelineTracker(),
 		queue:             newFinalizerQueue(),
+		inProgress:        make(map[relayKey]bool),
 
 		finalizerContext: finalizerContext,
 	}
@@ -64,17 +128,33 @@ func newFinalizerQueueProcessor(
 
 func newFinalizerQueue() *finalizerQueue {
 	return &finalizerQueue{
-		queue: make([]*queueItem, 0, 256),
+		queue: make(itemHeap, 0, 256),
+		ready: make(chan struct{}, 1),
 	}
 }
 
-func (q *finalizerQueue) Add(item *queueItem) {
+func (q *finalizerQueue) Add(item *queueItem, priority int) {
 	q.Lock()
 	defer q.Unlock()
 
-	q.queue = append(q.queue, item)
+	heap.Push(&q.queue, &prioritizedQueueItem{
+		item:     item,
+		priority: priority,
+		sequence: q.sequence,
+	})
+	q.sequence++
+	q.signal()
+}
+
+// Wakes up one waiting worker, does not block
+func (q *finalizerQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
 }
 
+// Returns the item with the highest priority or nil if the queue is empty
 func (q *finalizerQueue) Pop() *queueItem {
 	q.Lock()
 	defer q.Unlock()
@@ -82,10 +162,11 @@ func (q *finalizerQueue) Pop() *queueItem {
 	if len(q.queue) == 0 {
 		return nil
 	}
-
-	item := q.queue[0]
-	q.queue[0] = nil
-	q.queue = q.queue[1:]
+	item := heap.Pop(&q.queue).(*prioritizedQueueItem).item
+	if len(q.queue) > 0 {
+		// pass the signal to the next worker
+		q.signal()
+	}
 	return item
 }
 
@@ -95,45 +176,85 @@ func (p *finalizerQueueProcessor) Add(item *submitterPayloadItem, seed *big.Int)
 		votingRoundId: item.votingRoundId,
 		protocolId:    item.protocolId,
 		messageHash:   item.payload.messageHash,
-	})
+	}, p.finalizerContext.priority(item.protocolId))
 }
 
-// Infinite loop, should be run in a goroutine
+// Runs the worker pool, blocks until the context is cancelled
 func (p *finalizerQueueProcessor) Run(ctx context.Context) error {
-	ticker := time.NewTicker(finalizerQueueProcessorInterval)
+	eg, ctx := errgroup.WithContext(ctx)
+	workers := utils.Max(p.finalizerContext.workers, 1)
+	for i := 0; i < workers; i++ {
+		eg.Go(func() error {
+			return p.runWorker(ctx)
+		})
+	}
+	eg.Go(func() error {
+		return p.delayedQueues.Run(ctx)
+	})
+	err := eg.Wait()
+	logger.Info("Finalizer queue processor stopped")
+	return err
+}
+
+func (p *finalizerQueueProcessor) runWorker(ctx context.Context) error {
 	for {
-		select {
-		case <-ticker.C:
-			break
+		item := p.queue.Pop()
+		if item == nil {
+			select {
+			case <-p.queue.ready:
+				continue
 
-		case <-ctx.Done():
-			logger.Info("Finalizer queue processor stopped")
-			return ctx.Err()
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 
-		item := p.queue.Pop()
+		p.processQueueItem(ctx, item)
+	}
+}
 
-		if item == nil {
-			continue
+func (p *finalizerQueueProcessor) processQueueItem(ctx context.Context, item *queueItem) {
+	selected := p.isVoterForCurrentEpoch(item)
+	if data := p.submissionStorage.Get(item.votingRoundId, item.protocolId, item.messageHash); data != nil {
+		p.timeline.SelectionDecided(data.signingPolicy.rewardEpochId, item.key(), selected)
+	}
+	if p.finalizerContext.shadowMode {
+		p.shadowProcessItem(ctx, item, selected)
+		return
+	}
+	if selected {
+		logger.Info("Finalizer with address %v was selected for item %v", p.relayClient.senderAddress, item)
+
+		p.processItem(ctx, item, false, p.relayClients())
+	} else {
+		logger.Info("Finalizer with address %v will send outside grace period for item %v", p.relayClient.senderAddress, item)
+
+		data := p.submissionStorage.Get(item.votingRoundId, item.protocolId, item.messageHash)
+		if data != nil {
+			st := p.delayedSendTime(item)
+			logger.Info("Finalizer will send item %v at %v", item, st)
+			p.delayedQueues.Add(st, item)
 		}
+	}
+}
 
-		if p.isVoterForCurrentEpoch(item) {
-			logger.Info("Finalizer with address %v was selected for item %v", p.relayClient.senderAddress, item)
-
-			p.processItem(ctx, item, false)
-		} else {
-			logger.Info("Finalizer with address %v will send outside grace period for item %v", p.relayClient.senderAddress, item)
+// Marks the message as in progress, returns false if another worker is already relaying it
+func (p *finalizerQueueProcessor) startProcessing(key relayKey) bool {
+	p.inProgressMu.Lock()
+	defer p.inProgressMu.Unlock()
 
-			data := p.submissionStorage.Get(item.votingRoundId, item.protocolId, item.messageHash)
-			if data != nil {
-				// Finalization for a votingRoundId should happen in the following voting round votingRoundId + 1
-				votingRoundStartTime := p.finalizerContext.votingEpoch.StartTime(int64(item.votingRoundId + 1))
-				st := votingRoundStartTime.Add(p.finalizerContext.gracePeriodEndOffset)
-				logger.Info("Finalizer will send item %v at %v", item, st)
-				p.delayedQueues.Add(st, item)
-			}
-		}
+	if p.inProgress[key] {
+		return false
 	}
+	p.inProgress[key] = true
+	return true
+}
+
+func (p *finalizerQueueProcessor) finishProcessing(key relayKey) {
+	p.inProgressMu.Lock()
+	defer p.inProgressMu.Unlock()
+
+	delete(p.inProgress, key)
 }
 
 func (p *finalizerQueueProcessor) isVoterForCurrentEpoch(item *queueItem) bool {
@@ -155,62 +276,183 @@ func (p *finalizerQueueProcessor) isVoterForCurrentEpoch(item *queueItem) bool {
 	return voters.Contains(p.relayClient.senderAddress)
 }
 
-func (p *finalizerQueueProcessor) processItem(ctx context.Context, item *queueItem, isDelayed bool) {
-	if item == nil {
+// Returns relay clients for all chains, the default chain first
+func (p *finalizerQueueProcessor) relayClients() []*relayContractClient {
+	return append([]*relayContractClient{p.relayClient}, p.relayTargets...)
+}
+
+// Relays the item to the chains of the provided relay clients
+func (p *finalizerQueueProcessor) processItem(
+	ctx context.Context, item *queueItem, isDelayed bool, relayClients []*relayContractClient,
+) {
+	if item == nil || len(relayClients) == 0 {
 		return
 	}
+	key := item.key()
+	if !p.startProcessing(key) {
+		logger.Debug("Item %v is already being relayed", item)
+		return
+	}
+	defer p.finishProcessing(key)
+
 	data := p.submissionStorage.Get(item.votingRoundId, item.protocolId, item.messageHash)
 	if data == nil {
 		return
 	}
 
+	payloads := sortedPayloads(data)
+
+	// relay to all chains concurrently, each chain is tracked independently
+	var wg sync.WaitGroup
+	for _, relayClient := range relayClients {
+		wg.Add(1)
+		go func(relayClient *relayContractClient) {
+			defer wg.Done()
+			p.relayItem(ctx, item, data, payloads, relayClient, isDelayed)
+		}(relayClient)
+	}
+	wg.Wait()
+}
+
+// Selects signatures for the threshold the relay contract on the chain applies and relays them
+func (p *finalizerQueueProcessor) relayItem(
+	ctx context.Context, item *queueItem, data *messageData, payloads []*signedPayload,
+	relayClient *relayContractClient, isDelayed bool,
+) {
+	var result relayResult
+	var selected []*signedPayload
+
+	threshold, err := relayClient.RelayThreshold(ctx, data.signingPolicy, item.votingRoundId)
+	if err != nil {
+		result = relayResult{err: err}
+	} else if selected, err = selectPayloads(payloads, data.signingPolicy, threshold); err != nil {
+		logger.Info("Not relaying item %v on chain %s: %v", item, relayClient.chainName, err)
+		result = relayResult{err: err}
+	} else {
+		result = relayClient.SubmitPayloads(ctx, selected, data.signingPolicy, isDelayed)
+	}
+
+	if relayClient == p.relayClient && !result.sentAt.IsZero() {
+		p.timeline.TxSent(data.signingPolicy.rewardEpochId, item.key(), result.sentAt)
+		if result.receipt != nil {
+			p.timeline.TxMined(data.signingPolicy.rewardEpochId, item.key(), time.Now())
+		}
+	}
+
+	outcome := newFinalizationOutcome(item, data, selected, relayClient.chainName, !isDelayed)
+	outcome.setResult(result)
+	p.tracker.Record(outcome)
+}
+
+// Returns collected payloads of the message sorted decreasing by voter weight
+func sortedPayloads(data *messageData) []*signedPayload {
 	payloads := make([]*signedPayload, 0, len(data.payload))
 	for _, payload := range data.payload {
 		if payload != nil {
 			payloads = append(payloads, payload)
 		}
 	}
-
-	// (sort decreasing by weight)
 	slices.SortFunc(payloads, func(p, q *signedPayload) bool {
 		return data.signingPolicy.voters.VoterWeight(p.index) > data.signingPolicy.voters.VoterWeight(q.index)
 	})
+	return payloads
+}
 
-	// greedy select until threshold is reached
+// Greedily selects payloads (sorted decreasing by weight) until their weight exceeds
+// the threshold, returns the selection sorted by voter index
+func selectPayloads(payloads []*signedPayload, sp *signingPolicy, threshold uint16) ([]*signedPayload, error) {
 	weight := uint16(0)
 	var selected []*signedPayload
 	for _, payload := range payloads {
-		weight += data.signingPolicy.voters.VoterWeight(payload.index)
+		weight += sp.voters.VoterWeight(payload.index)
 		selected = append(selected, payload)
-		if weight > data.signingPolicy.threshold {
+		if weight > threshold {
 			break
 		}
 	}
+	if weight <= threshold {
+		return nil, fmt.Errorf("collected weight %d does not exceed relay threshold %d", weight, threshold)
+	}
 
-	// sort selected payloads by index
 	slices.SortFunc(selected, func(p, q *signedPayload) bool {
 		return p.index < q.index
 	})
+	return selected, nil
+}
 
-	p.relayClient.SubmitPayloads(ctx, selected, data.signingPolicy, isDelayed)
+func (p *finalizerQueueProcessor) processDelayedQueue(ctx context.Context, items []*queueItem) error {
+	if p.finalizerContext.shadowMode {
+		return p.shadowCompare(items)
+	}
+	for _, item := range items {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Relay status is re-checked immediately before sending, other finalizers
+		// may have relayed the message in the meantime
+		relayClients, err := p.unrelayedClients(ctx, item)
+		if err != nil {
+			logger.Error("Error checking relay status for item %v: %v", item, err)
+			continue
+		}
+		p.recordRelayedByOthers(item, relayClients)
+		if len(relayClients) == 0 {
+			logger.Info("Delayed queue item %v already relayed", item)
+			continue
+		}
+		logger.Info("Finalizer processes delayed queue item %v on %d chains", item, len(relayClients))
+		p.processItem(ctx, item, true, relayClients)
+	}
+	return nil
 }
 
-func (p *finalizerQueueProcessor) processDelayedQueue(items []*queueItem) error {
-	now := time.Now()
-	currentEpoch := p.finalizerContext.votingEpoch.EpochIndex(now)
-	startTime := p.finalizerContext.votingEpoch.StartTime(currentEpoch)
+// Records outcomes for chains on which the item was relayed by other finalizers
+func (p *finalizerQueueProcessor) recordRelayedByOthers(item *queueItem, unrelayedClients []*relayContractClient) {
+	data := p.submissionStorage.Get(item.votingRoundId, item.protocolId, item.messageHash)
+	for _, relayClient := range p.relayClients() {
+		if slices.Contains(unrelayedClients, relayClient) {
+			continue
+		}
+		outcome := newFinalizationOutcome(item, data, nil, relayClient.chainName, false)
+		outcome.setResult(relayResult{success: true, relayedByOthers: true})
+		p.tracker.Record(outcome)
+	}
+}
 
-	relayedItems, err := p.relayClient.ProtocolMessageRelayed(p.db, startTime, now)
+// Returns relay clients for chains on which the item was not relayed yet
+func (p *finalizerQueueProcessor) unrelayedClients(ctx context.Context, item *queueItem) ([]*relayContractClient, error) {
+	// Finalization for a votingRoundId happens in the following voting round votingRoundId + 1
+	startTime := p.finalizerContext.votingEpoch.StartTime(int64(item.votingRoundId) + 1)
+	relayedItems, err := p.relayClient.ProtocolMessageRelayed(p.db, startTime, time.Now())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, item := range items {
-		if relayedItems.Contains(*item) {
-			continue
+	var relayClients []*relayContractClient
+	if !relayedItems.Contains(item.key()) {
+		relayClients = append(relayClients, p.relayClient)
+	}
+	for _, target := range p.relayTargets {
+		relayed, err := target.IsRelayed(ctx, item.protocolId, item.votingRoundId)
+		if err != nil {
+			logger.Warn("Unable to check relay status for item %v: %v", item, err)
+		}
+		if !relayed {
+			relayClients = append(relayClients, target)
 		}
-		logger.Info("Finalizer processes delayed queue item %v", item)
-		p.processItem(context.TODO(), item, true)
 	}
-	return nil
+	return relayClients, nil
+}
+
+// Returns the time at which a non-selected finalizer sends the item: the end of
+// the grace period plus a random jitter, so that finalizers do not all send at once
+func (p *finalizerQueueProcessor) delayedSendTime(item *queueItem) time.Time {
+	// Finalization for a votingRoundId should happen in the following voting round votingRoundId + 1
+	votingRoundStartTime := p.finalizerContext.votingEpoch.StartTime(int64(item.votingRoundId + 1))
+	st := votingRoundStartTime.Add(p.finalizerContext.gracePeriodEndOffset)
+	if p.finalizerContext.gracePeriodJitter > 0 {
+		st = st.Add(time.Duration(rand.Int63n(int64(p.finalizerContext.gracePeriodJitter))))
+	}
+	return st
 }
@@ -0,0 +1,153 @@
+package finalizer
+
+import (
+	"flare-tlc/utils"
+	"math"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinalizerQueuePriority(t *testing.T) {
+	q := newFinalizerQueue()
+
+	q.Add(&queueItem{votingRoundId: 11, protocolId: 200}, 0)
+	q.Add(&queueItem{votingRoundId: 10, protocolId: 200}, 0)
+	q.Add(&queueItem{votingRoundId: 11, protocolId: 100}, 10)
+	q.Add(&queueItem{votingRoundId: 10, protocolId: 201}, 0)
+	q.Add(&queueItem{votingRoundId: 12, protocolId: 100}, 10)
+
+	expected := []struct {
+		votingRoundId uint32
+		protocolId    byte
+	}{
+		{11, 100},
+		{12, 100},
+		{10, 200},
+		{10, 201},
+		{11, 200},
+	}
+	for _, e := range expected {
+		item := q.Pop()
+		require.NotNil(t, item)
+		require.Equal(t, e.votingRoundId, item.votingRoundId)
+		require.Equal(t, e.protocolId, item.protocolId)
+	}
+	require.Nil(t, q.Pop())
+}
+
+func TestProtocolFilter(t *testing.T) {
+	cfg := map[string]int{"100": 10, "200": 1}
+	priority, err := parseProtocolPriority(cfg)
+	require.NoError(t, err)
+
+	_, err = parseProtocolPriority(map[string]int{"300": 1})
+	require.Error(t, err)
+
+	fCtx := &finalizerContext{protocolPriority: priority}
+	require.True(t, fCtx.isProtocolEnabled(1))
+	require.Equal(t, 10, fCtx.priority(100))
+	require.Equal(t, 0, fCtx.priority(1))
+
+	fCtx.allowedProtocols = mapset.NewSet[byte](100, 200)
+	fCtx.deniedProtocols = mapset.NewSet[byte](200)
+	require.True(t, fCtx.isProtocolEnabled(100))
+	require.False(t, fCtx.isProtocolEnabled(200))
+	require.False(t, fCtx.isProtocolEnabled(1))
+}
+
+func TestFinalizerQueueSignal(t *testing.T) {
+	q := newFinalizerQueue()
+
+	q.Add(&queueItem{votingRoundId: 1}, 0)
+	q.Add(&queueItem{votingRoundId: 2}, 0)
+	require.Len(t, q.ready, 1)
+
+	<-q.ready
+	require.NotNil(t, q.Pop())
+	// one item left, the signal is passed on to the next worker
+	require.Len(t, q.ready, 1)
+
+	<-q.ready
+	require.NotNil(t, q.Pop())
+	require.Empty(t, q.ready)
+	require.Nil(t, q.Pop())
+}
+
+func TestDelayedSendTime(t *testing.T) {
+	fCtx := &finalizerContext{
+		votingEpoch:          utils.NewEpoch(time.Unix(0, 0), 90*time.Second),
+		gracePeriodEndOffset: 40 * time.Second,
+		gracePeriodJitter:    5 * time.Second,
+	}
+	p := newFinalizerQueueProcessor(nil, newSubmissionStorage(false), nil, nil, fCtx, newFinalizationTracker())
+
+	gracePeriodEnd := time.Unix(11*90+40, 0)
+	for i := 0; i < 100; i++ {
+		st := p.delayedSendTime(&queueItem{votingRoundId: 10})
+		require.False(t, st.Before(gracePeriodEnd))
+		require.True(t, st.Before(gracePeriodEnd.Add(5*time.Second)))
+	}
+}
+
+func TestRelayThreshold(t *testing.T) {
+	sd := &relayStateData{
+		firstRewardEpochStartVotingRoundId: 1000,
+		rewardEpochDurationInVotingEpochs:  100,
+		thresholdIncreaseBIPS:              12000,
+		lastInitializedRewardEpoch:         5,
+	}
+	sp := &signingPolicy{rewardEpochId: 5, threshold: 500}
+
+	// voting round within the reward epoch of the signing policy
+	require.Equal(t, uint16(500), sd.threshold(sp, 1599))
+	// next reward epoch started, its signing policy is not initialized yet
+	require.Equal(t, uint16(600), sd.threshold(sp, 1600))
+
+	// signing policy is not the last initialized one
+	sd.lastInitializedRewardEpoch = 6
+	require.Equal(t, uint16(500), sd.threshold(sp, 1600))
+
+	// increased threshold is capped
+	sd.lastInitializedRewardEpoch = 5
+	sp.threshold = 60000
+	require.Equal(t, uint16(math.MaxUint16), sd.threshold(sp, 1600))
+}
+
+func TestShadowTrackerCompare(t *testing.T) {
+	tracker := newShadowTracker()
+	root := common.HexToHash("0x01")
+	other := common.HexToHash("0x02")
+
+	for i, r := range []*shadowResult{
+		{RewardEpochId: 1, VotingRoundId: 10, ProtocolId: 100, MerkleRoot: root},
+		{RewardEpochId: 1, VotingRoundId: 10, ProtocolId: 200, MerkleRoot: root},
+		{RewardEpochId: 1, VotingRoundId: 11, ProtocolId: 100, MerkleRoot: root},
+		{RewardEpochId: 2, VotingRoundId: 20, ProtocolId: 100, Error: "not enough weight"},
+	} {
+		r.Result = shadowResultPending
+		require.True(t, tracker.Add(r), i)
+	}
+	require.False(t, tracker.Add(&shadowResult{RewardEpochId: 1, VotingRoundId: 10, ProtocolId: 100}))
+
+	tracker.Compare(relayKey{protocolId: 100, votingRoundId: 10}, &root)
+	tracker.Compare(relayKey{protocolId: 200, votingRoundId: 10}, &other)
+	tracker.Compare(relayKey{protocolId: 100, votingRoundId: 11}, nil)
+	tracker.Compare(relayKey{protocolId: 100, votingRoundId: 20}, &root)
+	// compared results are final
+	tracker.Compare(relayKey{protocolId: 100, votingRoundId: 10}, &other)
+
+	results := tracker.Results(1)
+	require.Len(t, results, 3)
+	require.Equal(t, shadowResultAgree, results[0].Result)
+	require.Equal(t, shadowResultDisagree, results[1].Result)
+	require.Equal(t, shadowResultNotRelayed, results[2].Result)
+	require.Equal(t, shadowResultFailed, tracker.Results(2)[0].Result)
+
+	tracker.RemoveRewardEpochs([]uint32{1})
+	require.Empty(t, tracker.Results(1))
+	require.Len(t, tracker.Results(2), 1)
+}
@@ -0,0 +1,221 @@
+package finalizer
+
+import (
+	"context"
+	"flare-tlc/client/shared"
+	"flare-tlc/logger"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	shadowAPIPath = "finalizer/shadow"
+
+	// Comparison with relayed messages is done this long after non-selected finalizers send
+	shadowCompareDelay = 30 * time.Second
+
+	shadowResultPending    = "pending"
+	shadowResultAgree      = "agree"
+	shadowResultDisagree   = "disagree"
+	shadowResultNotRelayed = "not_relayed"
+	shadowResultFailed     = "failed"
+)
+
+var (
+	shadowComparisons = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "finalizer",
+		Name:      "shadow_comparisons_total",
+		Help:      "Shadow mode comparisons with relayed messages by result (agree, disagree, not_relayed, failed)",
+	}, []string{"selected", "result"})
+	shadowEstimatedGas = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "finalizer",
+		Name:      "shadow_estimated_gas_total",
+		Help:      "Estimated gas of relay transactions the finalizer would send in shadow mode",
+	})
+)
+
+// What the finalizer would relay in shadow mode and how it compares to the relayed message
+type shadowResult struct {
+	RewardEpochId     int64            `json:"rewardEpochId"`
+	VotingRoundId     uint32           `json:"votingRoundId"`
+	ProtocolId        byte             `json:"protocolId"`
+	Selected          bool             `json:"selected"`
+	MerkleRoot        common.Hash      `json:"merkleRoot"`
+	Signers           []common.Address `json:"signers"`
+	EstimatedGas      uint64           `json:"estimatedGas"`
+	Error             string           `json:"error,omitempty"`
+	RelayedMerkleRoot *common.Hash     `json:"relayedMerkleRoot,omitempty"`
+	Result            string           `json:"result"`
+	Timestamp         time.Time        `json:"timestamp"`
+}
+
+// Stores shadow mode results by protocol and voting round
+type shadowTracker struct {
+	results map[relayKey]*shadowResult
+
+	sync.Mutex
+}
+
+func newShadowTracker() *shadowTracker {
+	return &shadowTracker{
+		results: make(map[relayKey]*shadowResult),
+	}
+}
+
+// Adds the result, returns false if a result for the same protocol and voting round already exists
+func (t *shadowTracker) Add(r *shadowResult) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	key := relayKey{protocolId: r.ProtocolId, votingRoundId: r.VotingRoundId}
+	if _, ok := t.results[key]; ok {
+		return false
+	}
+	t.results[key] = r
+	return true
+}
+
+// Compares the pending result with the merkle root relayed on chain, nil if nothing was relayed
+func (t *shadowTracker) Compare(key relayKey, relayedMerkleRoot *common.Hash) {
+	t.Lock()
+	defer t.Unlock()
+
+	r, ok := t.results[key]
+	if !ok || r.Result != shadowResultPending {
+		return
+	}
+	r.RelayedMerkleRoot = relayedMerkleRoot
+	switch {
+	case r.Error != "":
+		r.Result = shadowResultFailed
+	case relayedMerkleRoot == nil:
+		r.Result = shadowResultNotRelayed
+	case *relayedMerkleRoot == r.MerkleRoot:
+		r.Result = shadowResultAgree
+	default:
+		r.Result = shadowResultDisagree
+	}
+	if r.Result == shadowResultDisagree {
+		logger.Warn("Shadow finalizer disagrees with relayed message for protocol %d in voting round %d: computed %v, relayed %v",
+			r.ProtocolId, r.VotingRoundId, r.MerkleRoot, *relayedMerkleRoot)
+	} else {
+		logger.Info("Shadow finalizer result for protocol %d in voting round %d: %s", r.ProtocolId, r.VotingRoundId, r.Result)
+	}
+	shadowComparisons.WithLabelValues(strconv.FormatBool(r.Selected), r.Result).Inc()
+}
+
+// Returns results for the reward epoch, ordered by voting round and protocol id
+func (t *shadowTracker) Results(rewardEpochId int64) []shadowResult {
+	t.Lock()
+	defer t.Unlock()
+
+	var result []shadowResult
+	for _, r := range t.results {
+		if r.RewardEpochId == rewardEpochId {
+			result = append(result, *r)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].VotingRoundId != result[j].VotingRoundId {
+			return result[i].VotingRoundId < result[j].VotingRoundId
+		}
+		return result[i].ProtocolId < result[j].ProtocolId
+	})
+	return result
+}
+
+func (t *shadowTracker) RemoveRewardEpochs(rewardEpochIds []uint32) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, id := range rewardEpochIds {
+		for key, r := range t.results {
+			if r.RewardEpochId == int64(id) {
+				delete(t.results, key)
+			}
+		}
+	}
+}
+
+// Registers handler for /api/finalizer/shadow?rewardEpochId=<id>
+func (t *shadowTracker) RegisterAPIHandlers() {
+	shared.RegisterAPIHandler(shadowAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		rewardEpochId, err := strconv.ParseInt(r.URL.Query().Get("rewardEpochId"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid or missing rewardEpochId parameter", http.StatusBadRequest)
+			return
+		}
+		shared.WriteJSONResponse(w, t.Results(rewardEpochId))
+	})
+}
+
+// Computes what would be relayed for the item on the default chain and schedules the
+// comparison with the relayed message, no transactions are sent
+func (p *finalizerQueueProcessor) shadowProcessItem(ctx context.Context, item *queueItem, selected bool) {
+	data := p.submissionStorage.Get(item.votingRoundId, item.protocolId, item.messageHash)
+	if data == nil {
+		return
+	}
+	r := &shadowResult{
+		RewardEpochId: data.signingPolicy.rewardEpochId,
+		VotingRoundId: item.votingRoundId,
+		ProtocolId:    item.protocolId,
+		Selected:      selected,
+		Result:        shadowResultPending,
+		Timestamp:     time.Now(),
+	}
+
+	threshold, err := p.relayClient.RelayThreshold(ctx, data.signingPolicy, item.votingRoundId)
+	var payloads []*signedPayload
+	if err == nil {
+		payloads, err = selectPayloads(sortedPayloads(data), data.signingPolicy, threshold)
+	}
+	if err == nil {
+		r.MerkleRoot = common.BytesToHash(payloads[0].message.merkleRoot)
+		for _, payload := range payloads {
+			r.Signers = append(r.Signers, payload.signer)
+		}
+		r.EstimatedGas, err = p.relayClient.EstimateRelay(ctx, payloads, data.signingPolicy)
+	}
+	switch {
+	case err != nil && shared.ExistsAsSubstring(nonFatalRelayErrors, err.Error()):
+		// relayed by another finalizer, the computed root is still compared with the relayed one
+		logger.Info("Shadow finalizer item %v already relayed", item)
+	case err != nil:
+		r.Error = err.Error()
+		logger.Info("Shadow finalizer would not relay item %v: %v", item, err)
+	default:
+		shadowEstimatedGas.Add(float64(r.EstimatedGas))
+		logger.Info("Shadow finalizer would relay item %v with %d signatures, estimated gas %d", item, len(payloads), r.EstimatedGas)
+	}
+
+	if p.shadowTracker.Add(r) {
+		p.delayedQueues.Add(p.delayedSendTime(item).Add(p.finalizerContext.gracePeriodJitter+shadowCompareDelay), item)
+	}
+}
+
+// Compares shadow results for the items with messages relayed by other finalizers
+func (p *finalizerQueueProcessor) shadowCompare(items []*queueItem) error {
+	for _, item := range items {
+		startTime := p.finalizerContext.votingEpoch.StartTime(int64(item.votingRoundId) + 1)
+		roots, err := p.relayClient.RelayedMerkleRoots(p.db, startTime, time.Now())
+		if err != nil {
+			logger.Error("Error fetching relayed messages for item %v: %v", item, err)
+			continue
+		}
+		key := item.key()
+		var relayedMerkleRoot *common.Hash
+		if root, ok := roots[key]; ok {
+			relayedMerkleRoot = &root
+		}
+		p.shadowTracker.Compare(key, relayedMerkleRoot)
+	}
+	return nil
+}
@@ -35,6 +35,9 @@ type signedPayload struct {
 
 	// index of voter in signing policy, updated when inserting it into storage
 	index int
+
+	// timestamp of the submitSignatures tx, set by the submission listener
+	timestamp int64
 }
 
 type submittedPayload struct {
@@ -6,21 +6,35 @@ import (
 	"crypto/ecdsa"
 	"flare-tlc/client/config"
 	"flare-tlc/client/shared"
+	globalConfig "flare-tlc/config"
 	"flare-tlc/database"
 	"flare-tlc/logger"
+	"flare-tlc/utils"
 	"flare-tlc/utils/chain"
 	"flare-tlc/utils/contracts/relay"
+	"math"
+	"math/big"
+	"sync"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/pkg/errors"
 )
 
 const (
 	listenerBufferSize = 10
+
+	// name of the chain the finalizer reads submissions from
+	defaultChainName = "default"
+
+	// max number of blocks in a single SigningPolicyInitialized logs query
+	signingPolicyFilterBlockRange = 1000
 )
 
 var (
@@ -30,7 +44,13 @@ var (
 )
 
 type relayContractClient struct {
-	address common.Address
+	chainName string
+	address   common.Address
+
+	// address and legacy addresses events are read from, rewardEpoch is
+	// used to determine until when legacy contracts are queried
+	history     *shared.ContractHistory
+	rewardEpoch *utils.Epoch
 
 	ethClient     relayEthClient
 	relay         *relay.Relay
@@ -43,15 +63,132 @@ type relayContractClient struct {
 }
 
 type relayEthClient interface {
-	SendRawTx(*ecdsa.PrivateKey, common.Address, []byte, bool) error
+	SendRawTx(*ecdsa.PrivateKey, common.Address, []byte, bool) (*types.Receipt, error)
+	CallContract(ctx context.Context, from common.Address, to common.Address, data []byte) error
+	EstimateGas(ctx context.Context, from common.Address, to common.Address, data []byte) (uint64, error)
+	RelayStateData(ctx context.Context, relayAddress common.Address) (*relayStateData, error)
+	SigningPolicyInitialized(
+		ctx context.Context, relayAddress common.Address, rewardEpochId int64, fromTimestamp, toTimestamp uint64,
+	) (*relay.RelaySigningPolicyInitialized, error)
 }
 
 type relayEthClientImpl struct {
 	client *ethclient.Client
+
+	// relay txs are sent concurrently by finalizer workers
+	nonceLock *sync.Mutex
+
+	blockNumbers *chain.BlockNumberCache
+}
+
+// Relay contract parameters that determine the threshold applied by the contract
+type relayStateData struct {
+	firstRewardEpochStartVotingRoundId uint32
+	rewardEpochDurationInVotingEpochs  uint16
+	thresholdIncreaseBIPS              uint16
+	lastInitializedRewardEpoch         uint32
+}
+
+func (eth relayEthClientImpl) SendRawTx(privateKey *ecdsa.PrivateKey, to common.Address, data []byte, dryRun bool) (*types.Receipt, error) {
+	return chain.SendRawTxConcurrent(eth.client, eth.nonceLock, privateKey, to, data, dryRun, &config.GasConfig{GasPriceFixed: common.Big0}, chain.DefaultTxTimeout)
+}
+
+// Executes the call without creating a tx (eth_call), returns an error if the call reverts
+func (eth relayEthClientImpl) CallContract(ctx context.Context, from common.Address, to common.Address, data []byte) error {
+	_, err := eth.client.CallContract(ctx, ethereum.CallMsg{
+		From: from,
+		To:   &to,
+		Data: data,
+	}, nil)
+	return err
+}
+
+func (eth relayEthClientImpl) EstimateGas(ctx context.Context, from common.Address, to common.Address, data []byte) (uint64, error) {
+	return eth.client.EstimateGas(ctx, ethereum.CallMsg{
+		From: from,
+		To:   &to,
+		Data: data,
+	})
+}
+
+func (eth relayEthClientImpl) RelayStateData(ctx context.Context, relayAddress common.Address) (*relayStateData, error) {
+	relayContract, err := relay.NewRelay(relayAddress, eth.client)
+	if err != nil {
+		return nil, err
+	}
+	sd, err := relayContract.StateData(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	return &relayStateData{
+		firstRewardEpochStartVotingRoundId: sd.FirstRewardEpochStartVotingRoundId,
+		rewardEpochDurationInVotingEpochs:  sd.RewardEpochDurationInVotingEpochs,
+		thresholdIncreaseBIPS:              sd.ThresholdIncreaseBIPS,
+		lastInitializedRewardEpoch:         sd.LastInitializedRewardEpoch,
+	}, nil
+}
+
+// Returns the SigningPolicyInitialized event for the reward epoch emitted between the timestamps,
+// or nil if there is no such event. Logs are filtered in ranges of at most
+// signingPolicyFilterBlockRange blocks, RPC nodes limit the block range of a single query.
+func (eth relayEthClientImpl) SigningPolicyInitialized(
+	ctx context.Context, relayAddress common.Address, rewardEpochId int64, fromTimestamp, toTimestamp uint64,
+) (*relay.RelaySigningPolicyInitialized, error) {
+	relayContract, err := relay.NewRelay(relayAddress, eth.client)
+	if err != nil {
+		return nil, err
+	}
+	fromBlock, err := eth.blockNumbers.BlockNumberByTimestamp(ctx, fromTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	toBlock, err := eth.blockNumbers.BlockNumberByTimestamp(ctx, toTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	for start := fromBlock; start <= toBlock; start += signingPolicyFilterBlockRange {
+		end := utils.Min(start+signingPolicyFilterBlockRange-1, toBlock)
+		event, err := filterSigningPolicyInitialized(ctx, relayContract, rewardEpochId, start, end)
+		if err != nil || event != nil {
+			return event, err
+		}
+	}
+	return nil, nil
 }
 
-func (eth relayEthClientImpl) SendRawTx(privateKey *ecdsa.PrivateKey, to common.Address, data []byte, dryRun bool) error {
-	return chain.SendRawTx(eth.client, privateKey, to, data, dryRun, &config.GasConfig{GasPriceFixed: common.Big0}, chain.DefaultTxTimeout)
+func filterSigningPolicyInitialized(
+	ctx context.Context, relayContract *relay.Relay, rewardEpochId int64, fromBlock, toBlock uint64,
+) (*relay.RelaySigningPolicyInitialized, error) {
+	it, err := relayContract.FilterSigningPolicyInitialized(
+		&bind.FilterOpts{Start: fromBlock, End: &toBlock, Context: ctx},
+		[]*big.Int{big.NewInt(rewardEpochId)},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	if it.Next() {
+		return it.Event, nil
+	}
+	return nil, it.Error()
+}
+
+// Returns the threshold the relay contract applies to messages of the voting round signed
+// by the signing policy. If the policy is the last initialized one and the voting round
+// belongs to a later reward epoch (signing policy initialization is late), the contract
+// increases the threshold by thresholdIncreaseBIPS.
+func (sd *relayStateData) threshold(sp *signingPolicy, votingRoundId uint32) uint16 {
+	nextRewardEpochStart := uint64(sd.firstRewardEpochStartVotingRoundId) +
+		uint64(sp.rewardEpochId+1)*uint64(sd.rewardEpochDurationInVotingEpochs)
+	if uint32(sp.rewardEpochId) != sd.lastInitializedRewardEpoch || uint64(votingRoundId) < nextRewardEpochStart {
+		return sp.threshold
+	}
+	increased := uint64(sp.threshold) * uint64(sd.thresholdIncreaseBIPS) / 10000
+	if increased > math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(increased)
 }
 
 type signingPolicyListenerResponse struct {
@@ -88,8 +225,14 @@ func NewRelayContractClient(
 	}
 
 	return &relayContractClient{
-		ethClient:     relayEthClientImpl{client: ethClient},
+		chainName: defaultChainName,
+		ethClient: relayEthClientImpl{
+			client:       ethClient,
+			nonceLock:    &sync.Mutex{},
+			blockNumbers: chain.NewBlockNumberCache(ethClient),
+		},
 		address:       address,
+		history:       shared.NewContractHistory(address, nil),
 		relay:         relayContract,
 		privateKey:    privateKey,
 		senderAddress: senderAddress,
@@ -99,36 +242,39 @@ func NewRelayContractClient(
 	}, nil
 }
 
-func (r *relayContractClient) FetchSigningPolicies(db finalizerDB, from, to int64) ([]signingPolicyListenerResponse, error) {
-	var allLogs []database.Log
-
-	// TEMP CHANGE for upgrading Relay contract, should be removed after 17 Oct 2024
-
-	// If using new Songbird Relay, query the old one as well.
-	// Note: this won't have any effect on other networks as we currently have unique Relay addresses for each network.
-	if r.address == common.HexToAddress("0x67a916E175a2aF01369294739AA60dDdE1Fad189") {
-		logsOld, err := db.FetchLogsByAddressAndTopic0(common.HexToAddress("0xbA35e39D01A3f5710d1e43FC61dbb738B68641c4"), r.topic0SPI, from, to)
-		if err != nil {
-			return nil, err
-		}
-		allLogs = append(allLogs, logsOld...)
+// Creates a relay contract client for sending relay transactions to an additional chain
+func NewRelayTargetClient(cfg *config.RelayTargetConfig) (*relayContractClient, error) {
+	ethClient, err := cfg.Chain.DialETH()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error dialing relay target %s", cfg.Name)
 	}
-	// If using new Coston Relay, query the old one as well.
-	if r.address == common.HexToAddress("0x92a6E1127262106611e1e129BB64B6D8654273F7") {
-		logsOld, err := db.FetchLogsByAddressAndTopic0(common.HexToAddress("0xA300E71257547e645CD7241987D3B75f2012E0E3"), r.topic0SPI, from, to)
-		if err != nil {
-			return nil, err
-		}
-		allLogs = append(allLogs, logsOld...)
+	privateKey, err := globalConfig.PrivateKeyFromConfig(cfg.SenderPrivateKeyFile, cfg.SenderPrivateKey())
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading sender private key for relay target %s", cfg.Name)
 	}
-	// END TEMP CHANGE
-
-	logs, err := db.FetchLogsByAddressAndTopic0(r.address, r.topic0SPI, from, to)
+	senderAddress, err := chain.PrivateKeyToEthAddress(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewRelayContractClient(ethClient, cfg.Relay, privateKey, senderAddress)
 	if err != nil {
 		return nil, err
 	}
+	client.chainName = cfg.Name
+	return client, nil
+}
+
+// Sets previous relay deployments, their SigningPolicyInitialized events are also read
+func (r *relayContractClient) SetLegacyAddresses(legacy []globalConfig.LegacyContractAddress, rewardEpoch *utils.Epoch) {
+	r.history = shared.NewContractHistory(r.address, legacy)
+	r.rewardEpoch = rewardEpoch
+}
 
-	allLogs = append(allLogs, logs...)
+func (r *relayContractClient) FetchSigningPolicies(db finalizerDB, from, to int64) ([]signingPolicyListenerResponse, error) {
+	allLogs, err := r.history.FetchLogs(db, r.topic0SPI, from, to, r.rewardEpoch)
+	if err != nil {
+		return nil, err
+	}
 
 	result := make([]signingPolicyListenerResponse, 0, len(allLogs))
 	for _, log := range allLogs {
@@ -142,38 +288,37 @@ func (r *relayContractClient) FetchSigningPolicies(db finalizerDB, from, to int6
 	return result, nil
 }
 
-func (r *relayContractClient) SigningPolicyInitializedListener(db finalizerDB, startTime time.Time) <-chan signingPolicyListenerResponse {
-	out := make(chan signingPolicyListenerResponse, listenerBufferSize)
-	go func() {
-		ticker := time.NewTicker(shared.EventListenerInterval)
-		eventRangeStart := startTime.Unix()
-		for {
-			<-ticker.C
-			now := time.Now().Unix()
-			logs, err := db.FetchLogsByAddressAndTopic0(r.address, r.topic0SPI, eventRangeStart, now)
+func (r *relayContractClient) SigningPolicyInitializedListener(ctx context.Context, db finalizerDB, startTime time.Time) <-chan signingPolicyListenerResponse {
+	stream := &shared.EventStream[signingPolicyListenerResponse]{
+		Name:        "SigningPolicyInitialized",
+		History:     r.history,
+		RewardEpoch: r.rewardEpoch,
+		Topic0:      r.topic0SPI,
+		Parse: func(log database.Log) (signingPolicyListenerResponse, error) {
+			policyData, err := shared.ParseSigningPolicyInitializedEvent(r.relay, log)
 			if err != nil {
-				logger.Error("Error fetching logs %v", err)
-				continue
+				return signingPolicyListenerResponse{}, err
 			}
-			for _, log := range logs {
-				policyData, err := shared.ParseSigningPolicyInitializedEvent(r.relay, log)
-				if err != nil {
-					logger.Error("Error parsing SigningPolicyInitialized event %v", err)
-					break
-				}
-				out <- signingPolicyListenerResponse{policyData, int64(log.Timestamp)}
-				// continue with timestamps > log.Timestamp,
-				// there should be only one such log per timestamp
-				eventRangeStart = int64(log.Timestamp)
-			}
-		}
-	}()
-	return out
+			return signingPolicyListenerResponse{policyData, int64(log.Timestamp)}, nil
+		},
+		BufferSize: listenerBufferSize,
+	}
+	return stream.Start(ctx, db, startTime)
 }
 
-func (r *relayContractClient) SubmitPayloads(ctx context.Context, payloads []*signedPayload, signingPolicy *signingPolicy, dryRun bool) {
+// Result of sending a relay tx
+type relayResult struct {
+	success         bool
+	relayedByOthers bool           // relay tx reverted because the message was already relayed
+	receipt         *types.Receipt // nil if the tx was not mined
+	sentAt          time.Time      // zero if no relay tx was sent
+	err             error
+}
+
+// Returns relay tx data for the signed payloads
+func (r *relayContractClient) relayTxData(payloads []*signedPayload, signingPolicy *signingPolicy) ([]byte, error) {
 	if len(payloads) == 0 || signingPolicy == nil {
-		return
+		return nil, errors.New("no payloads or signing policy")
 	}
 
 	buffer := bytes.NewBuffer(nil)
@@ -183,51 +328,158 @@ func (r *relayContractClient) SubmitPayloads(ctx context.Context, payloads []*si
 	signatureBytes, err := EncodeForRelay(payloads)
 	if err != nil {
 		logger.Error("Error encoding payloads %v", err)
-		return
+		return nil, err
 	}
 	buffer.Write(signatureBytes)
-	payload := buffer.Bytes()
+	return buffer.Bytes(), nil
+}
 
-	execStatusChan := shared.ExecuteWithRetry(func() (any, error) {
-		err := r.ethClient.SendRawTx(r.privateKey, r.address, payload, dryRun)
+// Simulates the relay tx without sending it, returns the estimated gas
+func (r *relayContractClient) EstimateRelay(ctx context.Context, payloads []*signedPayload, signingPolicy *signingPolicy) (uint64, error) {
+	payload, err := r.relayTxData(payloads, signingPolicy)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.ethClient.CallContract(ctx, r.senderAddress, r.address, payload); err != nil {
+		return 0, err
+	}
+	return r.ethClient.EstimateGas(ctx, r.senderAddress, r.address, payload)
+}
+
+func (r *relayContractClient) SubmitPayloads(ctx context.Context, payloads []*signedPayload, signingPolicy *signingPolicy, dryRun bool) relayResult {
+	payload, err := r.relayTxData(payloads, signingPolicy)
+	if err != nil {
+		return relayResult{err: err}
+	}
+
+	// Pre-flight check, do not waste gas on a relay tx that would revert
+	err = r.ethClient.CallContract(ctx, r.senderAddress, r.address, payload)
+	if err != nil {
+		if shared.ExistsAsSubstring(nonFatalRelayErrors, err.Error()) {
+			logger.Info("Message already relayed on chain %s, skipping relay tx", r.chainName)
+			return relayResult{success: true, relayedByOthers: true}
+		}
+		logger.Warn("Relay pre-flight check failed on chain %s: %v", r.chainName, err)
+		return relayResult{err: errors.Wrap(err, "pre-flight check failed")}
+	}
+
+	sentAt := time.Now()
+	execStatusChan := shared.ExecuteWithRetry(func() (relayResult, error) {
+		receipt, err := r.ethClient.SendRawTx(r.privateKey, r.address, payload, dryRun)
 		if err != nil {
 			if shared.ExistsAsSubstring(nonFatalRelayErrors, err.Error()) {
 				logger.Info("Non fatal error sending relay tx: %v", err)
+				return relayResult{success: true, relayedByOthers: true, sentAt: sentAt}, nil
 			} else {
-				return nil, errors.Wrap(err, "Error sending relay tx")
+				return relayResult{}, errors.Wrapf(err, "Error sending relay tx on chain %s", r.chainName)
 			}
 		}
-		return nil, nil
+		return relayResult{success: true, receipt: receipt, sentAt: sentAt}, nil
 	}, shared.MaxTxSendRetries, shared.TxRetryInterval)
 
 	select {
 	case execStatus := <-execStatusChan:
 		if execStatus.Success {
-			logger.Info("Relaying finished")
+			logger.Info("Relaying finished on chain %s", r.chainName)
+			return execStatus.Value
 		}
+		return relayResult{err: errors.New(execStatus.Message), sentAt: sentAt}
 
 	case <-ctx.Done():
-		return
+		return relayResult{err: ctx.Err()}
 	}
 }
 
-func (r *relayContractClient) ProtocolMessageRelayed(db finalizerDB, from time.Time, to time.Time) (mapset.Set[queueItem], error) {
+// Returns protocol ids and voting rounds of messages relayed in the time range
+func (r *relayContractClient) ProtocolMessageRelayed(db finalizerDB, from time.Time, to time.Time) (mapset.Set[relayKey], error) {
 	logs, err := db.FetchLogsByAddressAndTopic0(r.address, r.topic0PMR, from.Unix(), to.Unix())
 	if err != nil {
 		return nil, err
 	}
 
-	result := mapset.NewSet[queueItem]()
+	result := mapset.NewSet[relayKey]()
 	for _, log := range logs {
 		data, err := shared.ParseProtocolMessageRelayedEvent(r.relay, log)
 		if err != nil {
 			return nil, err
 		}
-		result.Add(queueItem{
+		result.Add(relayKey{
 			protocolId:    data.ProtocolId,
 			votingRoundId: data.VotingRoundId,
-			messageHash:   data.MerkleRoot,
 		})
 	}
 	return result, nil
 }
+
+// Message relayed on the default chain and the block timestamp of the relay
+type protocolMessageRelayed struct {
+	key       relayKey
+	timestamp int64
+}
+
+func (r *relayContractClient) ProtocolMessageRelayedListener(ctx context.Context, db finalizerDB, startTime time.Time) <-chan protocolMessageRelayed {
+	stream := &shared.EventStream[protocolMessageRelayed]{
+		Name:    "ProtocolMessageRelayed",
+		History: shared.NewContractHistory(r.address, nil),
+		Topic0:  r.topic0PMR,
+		Parse: func(log database.Log) (protocolMessageRelayed, error) {
+			data, err := shared.ParseProtocolMessageRelayedEvent(r.relay, log)
+			if err != nil {
+				return protocolMessageRelayed{}, err
+			}
+			return protocolMessageRelayed{
+				key:       relayKey{protocolId: data.ProtocolId, votingRoundId: data.VotingRoundId},
+				timestamp: int64(log.Timestamp),
+			}, nil
+		},
+		BufferSize: listenerBufferSize,
+	}
+	return stream.Start(ctx, db, startTime)
+}
+
+// Returns merkle roots of messages relayed in the time range
+func (r *relayContractClient) RelayedMerkleRoots(db finalizerDB, from time.Time, to time.Time) (map[relayKey]common.Hash, error) {
+	logs, err := db.FetchLogsByAddressAndTopic0(r.address, r.topic0PMR, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[relayKey]common.Hash, len(logs))
+	for _, log := range logs {
+		data, err := shared.ParseProtocolMessageRelayedEvent(r.relay, log)
+		if err != nil {
+			return nil, err
+		}
+		result[relayKey{protocolId: data.ProtocolId, votingRoundId: data.VotingRoundId}] = data.MerkleRoot
+	}
+	return result, nil
+}
+
+// Fetches the signing policy for the reward epoch directly from the chain, returns nil if the
+// SigningPolicyInitialized event was not emitted between the timestamps
+func (r *relayContractClient) FetchSigningPolicyFromChain(
+	ctx context.Context, rewardEpochId int64, fromTimestamp, toTimestamp uint64,
+) (*relay.RelaySigningPolicyInitialized, error) {
+	return r.ethClient.SigningPolicyInitialized(ctx, r.address, rewardEpochId, fromTimestamp, toTimestamp)
+}
+
+// Returns the threshold that the relay contract on the chain of this client applies to the voting round
+func (r *relayContractClient) RelayThreshold(ctx context.Context, sp *signingPolicy, votingRoundId uint32) (uint16, error) {
+	sd, err := r.ethClient.RelayStateData(ctx, r.address)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error fetching relay state data on chain %s", r.chainName)
+	}
+	return sd.threshold(sp, votingRoundId), nil
+}
+
+// Returns true if a merkle root for the protocol and voting round is already confirmed on the
+// chain of this client, queried directly from the relay contract
+func (r *relayContractClient) IsRelayed(ctx context.Context, protocolId byte, votingRoundId uint32) (bool, error) {
+	root, err := r.relay.MerkleRoots(
+		&bind.CallOpts{Context: ctx}, big.NewInt(int64(protocolId)), big.NewInt(int64(votingRoundId)),
+	)
+	if err != nil {
+		return false, errors.Wrapf(err, "error fetching merkle root on chain %s", r.chainName)
+	}
+	return root != [32]byte{}, nil
+}
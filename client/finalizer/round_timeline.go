@@ -0,0 +1,172 @@
+package finalizer
+
+import (
+	"flare-tlc/client/shared"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const timelineAPIPath = "finalizer/timeline"
+
+// Timestamps of finalization steps for a protocol message in a voting round. Signature and
+// relay timestamps are block timestamps, the others are local times of finalizer actions.
+// Tx timestamps are for the default chain only.
+type roundTimeline struct {
+	RewardEpochId     int64      `json:"rewardEpochId"`
+	VotingRoundId     uint32     `json:"votingRoundId"`
+	ProtocolId        byte       `json:"protocolId"`
+	FirstSignature    *time.Time `json:"firstSignature,omitempty"`
+	ThresholdReached  *time.Time `json:"thresholdReached,omitempty"`
+	SelectionDecision *time.Time `json:"selectionDecision,omitempty"`
+	Selected          bool       `json:"selected"`
+	TxSent            *time.Time `json:"txSent,omitempty"`
+	TxMined           *time.Time `json:"txMined,omitempty"`
+	MessageRelayed    *time.Time `json:"messageRelayed,omitempty"`
+}
+
+// Collects finalization timelines by protocol and voting round
+type timelineTracker struct {
+	timelines map[relayKey]*roundTimeline
+
+	sync.Mutex
+}
+
+func newTimelineTracker() *timelineTracker {
+	return &timelineTracker{
+		timelines: make(map[relayKey]*roundTimeline),
+	}
+}
+
+// Applies the update to the timeline of the message, creating the timeline if needed
+func (t *timelineTracker) update(rewardEpochId int64, key relayKey, f func(*roundTimeline)) {
+	t.Lock()
+	defer t.Unlock()
+
+	tl, ok := t.timelines[key]
+	if !ok {
+		tl = &roundTimeline{
+			RewardEpochId: rewardEpochId,
+			VotingRoundId: key.votingRoundId,
+			ProtocolId:    key.protocolId,
+		}
+		t.timelines[key] = tl
+	}
+	f(tl)
+}
+
+// Sets the timestamp if it is earlier than the stored one, the first occurrence of a step is kept
+func setEarliest(field **time.Time, ts time.Time) {
+	if *field == nil || ts.Before(**field) {
+		*field = &ts
+	}
+}
+
+func (t *timelineTracker) SignatureSeen(rewardEpochId int64, key relayKey, ts time.Time) {
+	t.update(rewardEpochId, key, func(tl *roundTimeline) { setEarliest(&tl.FirstSignature, ts) })
+}
+
+func (t *timelineTracker) ThresholdReached(rewardEpochId int64, key relayKey, ts time.Time) {
+	t.update(rewardEpochId, key, func(tl *roundTimeline) { setEarliest(&tl.ThresholdReached, ts) })
+}
+
+func (t *timelineTracker) SelectionDecided(rewardEpochId int64, key relayKey, selected bool) {
+	t.update(rewardEpochId, key, func(tl *roundTimeline) {
+		if tl.SelectionDecision == nil {
+			now := time.Now()
+			tl.SelectionDecision = &now
+			tl.Selected = selected
+		}
+	})
+}
+
+func (t *timelineTracker) TxSent(rewardEpochId int64, key relayKey, ts time.Time) {
+	t.update(rewardEpochId, key, func(tl *roundTimeline) { setEarliest(&tl.TxSent, ts) })
+}
+
+func (t *timelineTracker) TxMined(rewardEpochId int64, key relayKey, ts time.Time) {
+	t.update(rewardEpochId, key, func(tl *roundTimeline) { setEarliest(&tl.TxMined, ts) })
+}
+
+func (t *timelineTracker) MessageRelayed(rewardEpochId int64, key relayKey, ts time.Time) {
+	t.update(rewardEpochId, key, func(tl *roundTimeline) { setEarliest(&tl.MessageRelayed, ts) })
+}
+
+// Returns timelines matching the filter, ordered by voting round and protocol id
+func (t *timelineTracker) Timelines(filter func(*roundTimeline) bool) []roundTimeline {
+	t.Lock()
+	defer t.Unlock()
+
+	var result []roundTimeline
+	for _, tl := range t.timelines {
+		if filter(tl) {
+			result = append(result, *tl)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].VotingRoundId != result[j].VotingRoundId {
+			return result[i].VotingRoundId < result[j].VotingRoundId
+		}
+		return result[i].ProtocolId < result[j].ProtocolId
+	})
+	return result
+}
+
+func (t *timelineTracker) RemoveRewardEpochs(rewardEpochIds []uint32) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, id := range rewardEpochIds {
+		for key, tl := range t.timelines {
+			if tl.RewardEpochId == int64(id) {
+				delete(t.timelines, key)
+			}
+		}
+	}
+}
+
+// Registers handler for /api/finalizer/timeline?(votingRoundId=<id>|rewardEpochId=<id>)[&protocolId=<id>]
+func (t *timelineTracker) RegisterAPIHandlers() {
+	shared.RegisterAPIHandler(timelineAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var filters []func(*roundTimeline) bool
+		if s := query.Get("votingRoundId"); s != "" {
+			votingRoundId, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				http.Error(w, "invalid votingRoundId parameter", http.StatusBadRequest)
+				return
+			}
+			filters = append(filters, func(tl *roundTimeline) bool { return tl.VotingRoundId == uint32(votingRoundId) })
+		}
+		if s := query.Get("rewardEpochId"); s != "" {
+			rewardEpochId, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid rewardEpochId parameter", http.StatusBadRequest)
+				return
+			}
+			filters = append(filters, func(tl *roundTimeline) bool { return tl.RewardEpochId == rewardEpochId })
+		}
+		if len(filters) == 0 {
+			http.Error(w, "votingRoundId or rewardEpochId parameter is required", http.StatusBadRequest)
+			return
+		}
+		if s := query.Get("protocolId"); s != "" {
+			protocolId, err := strconv.ParseUint(s, 10, 8)
+			if err != nil {
+				http.Error(w, "invalid protocolId parameter", http.StatusBadRequest)
+				return
+			}
+			filters = append(filters, func(tl *roundTimeline) bool { return tl.ProtocolId == byte(protocolId) })
+		}
+		shared.WriteJSONResponse(w, t.Timelines(func(tl *roundTimeline) bool {
+			for _, f := range filters {
+				if !f(tl) {
+					return false
+				}
+			}
+			return true
+		}))
+	})
+}
@@ -0,0 +1,103 @@
+package finalizer
+
+import (
+	"encoding/json"
+	"flag"
+	clientContext "flare-tlc/client/context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const timelineTimeFormat = "15:04:05.000"
+
+// Prints finalization timelines collected by a running client, usage:
+//
+//	timeline -round <votingRoundId> [-protocol <protocolId>] [-api <url>]
+func TimelineCommand(ctx clientContext.ClientContext, args []string) error {
+	fs := flag.NewFlagSet("timeline", flag.ContinueOnError)
+	votingRoundId := fs.Uint("round", 0, "Voting round id")
+	rewardEpochId := fs.Int64("reward-epoch", -1, "Reward epoch id, used if voting round id is not set")
+	protocolId := fs.Int("protocol", -1, "Protocol id, all protocols if not set")
+	apiURL := fs.String("api", "", "Client API base url, default is http://<metrics.prometheus_address>/api")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	base := *apiURL
+	if base == "" {
+		address := ctx.Config().Metrics.PrometheusAddress
+		if address == "" {
+			return errors.New("metrics.prometheus_address is not set, use -api to set the client API url")
+		}
+		if strings.HasPrefix(address, ":") {
+			address = "localhost" + address
+		}
+		base = "http://" + address + "/api"
+	}
+	query := url.Values{}
+	switch {
+	case *votingRoundId > 0:
+		query.Set("votingRoundId", strconv.FormatUint(uint64(*votingRoundId), 10))
+	case *rewardEpochId >= 0:
+		query.Set("rewardEpochId", strconv.FormatInt(*rewardEpochId, 10))
+	default:
+		return errors.New("-round or -reward-epoch must be set")
+	}
+	if *protocolId >= 0 {
+		query.Set("protocolId", strconv.Itoa(*protocolId))
+	}
+
+	timelines, err := fetchTimelines(strings.TrimSuffix(base, "/") + "/" + timelineAPIPath + "?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	return writeTimelines(os.Stdout, timelines)
+}
+
+func fetchTimelines(url string) ([]roundTimeline, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "error fetching timeline")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("error fetching timeline: %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var timelines []roundTimeline
+	if err := json.NewDecoder(resp.Body).Decode(&timelines); err != nil {
+		return nil, errors.Wrap(err, "error decoding timeline")
+	}
+	return timelines, nil
+}
+
+func writeTimelines(w io.Writer, timelines []roundTimeline) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REWARD EPOCH\tVOTING ROUND\tPROTOCOL\tFIRST SIGNATURE\tTHRESHOLD REACHED\tSELECTION\tSELECTED\tTX SENT\tTX MINED\tRELAYED")
+	for _, tl := range timelines {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%s\t%s\t%t\t%s\t%s\t%s\n",
+			tl.RewardEpochId, tl.VotingRoundId, tl.ProtocolId,
+			formatTimelineTime(tl.FirstSignature), formatTimelineTime(tl.ThresholdReached),
+			formatTimelineTime(tl.SelectionDecision), tl.Selected,
+			formatTimelineTime(tl.TxSent), formatTimelineTime(tl.TxMined), formatTimelineTime(tl.MessageRelayed),
+		)
+	}
+	return tw.Flush()
+}
+
+func formatTimelineTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(timelineTimeFormat)
+}
@@ -0,0 +1,66 @@
+package finalizer
+
+import (
+	"bytes"
+	"flare-tlc/client/shared"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimelineTracker(t *testing.T) {
+	tracker := newTimelineTracker()
+	key := relayKey{protocolId: 100, votingRoundId: 10}
+	start := time.Unix(1000, 0)
+
+	// signatures may be processed out of order, the earliest one is kept
+	tracker.SignatureSeen(1, key, start.Add(2*time.Second))
+	tracker.SignatureSeen(1, key, start)
+	tracker.ThresholdReached(1, key, start.Add(3*time.Second))
+	tracker.SelectionDecided(1, key, true)
+	tracker.SelectionDecided(1, key, false)
+	tracker.MessageRelayed(1, key, start.Add(10*time.Second))
+	tracker.SignatureSeen(1, relayKey{protocolId: 200, votingRoundId: 10}, start)
+	tracker.SignatureSeen(2, relayKey{protocolId: 100, votingRoundId: 110}, start)
+
+	timelines := tracker.Timelines(func(tl *roundTimeline) bool { return tl.VotingRoundId == 10 })
+	require.Len(t, timelines, 2)
+	tl := timelines[0]
+	require.Equal(t, byte(100), tl.ProtocolId)
+	require.Equal(t, start, *tl.FirstSignature)
+	require.Equal(t, start.Add(3*time.Second), *tl.ThresholdReached)
+	require.NotNil(t, tl.SelectionDecision)
+	require.True(t, tl.Selected)
+	require.Nil(t, tl.TxSent)
+	require.Equal(t, start.Add(10*time.Second), *tl.MessageRelayed)
+
+	tracker.RemoveRewardEpochs([]uint32{1})
+	require.Len(t, tracker.Timelines(func(*roundTimeline) bool { return true }), 1)
+}
+
+func TestTimelineCommandOutput(t *testing.T) {
+	tracker := newTimelineTracker()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)
+	tracker.SignatureSeen(1, relayKey{protocolId: 100, votingRoundId: 10}, start)
+	tracker.ThresholdReached(1, relayKey{protocolId: 100, votingRoundId: 10}, start.Add(1500*time.Millisecond))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "10", r.URL.Query().Get("votingRoundId"))
+		shared.WriteJSONResponse(w, tracker.Timelines(func(*roundTimeline) bool { return true }))
+	}))
+	defer server.Close()
+
+	timelines, err := fetchTimelines(server.URL + "/" + timelineAPIPath + "?votingRoundId=10")
+	require.NoError(t, err)
+	require.Len(t, timelines, 1)
+
+	var out bytes.Buffer
+	require.NoError(t, writeTimelines(&out, timelines))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, []string{"1", "10", "100", "10:00:00.000", "10:00:01.500", "-", "false", "-", "-", "-"}, strings.Fields(lines[1]))
+}
@@ -0,0 +1,247 @@
+package finalizer
+
+import (
+	"context"
+	"flag"
+	clientConfig "flare-tlc/client/config"
+	clientContext "flare-tlc/client/context"
+	"flare-tlc/config"
+	"flare-tlc/utils/contracts/relay"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// Selected finalizers of a protocol in a voting round
+type voterSelection struct {
+	votingRoundId uint32
+	protocolId    byte
+	selected      []common.Address // sorted by voter index
+	ours          bool
+}
+
+// Result of the finalizer selection simulation for a range of voting rounds
+type selectionSimulation struct {
+	rewardEpochId int64
+	thresholdBIPS uint16
+	address       common.Address
+	selections    []voterSelection
+}
+
+// Prints finalizers selected in voting rounds of a reward epoch, usage:
+//
+//	select-voters -reward-epoch <id> [-from <votingRoundId>] [-to <votingRoundId>] [-protocols <id,id>]
+//	              [-threshold-bips <bips>] [-address <address>]
+func SelectVotersCommand(ctx clientContext.ClientContext, args []string) error {
+	cfg := ctx.Config()
+
+	fs := flag.NewFlagSet("select-voters", flag.ContinueOnError)
+	rewardEpochId := fs.Int64("reward-epoch", -1, "Reward epoch id")
+	from := fs.Uint("from", 0, "First voting round, default is the first voting round of the reward epoch")
+	to := fs.Uint("to", 0, "Last voting round (inclusive), default is the last expected voting round of the reward epoch")
+	protocols := fs.String("protocols", "", "Comma separated protocol ids, default are the configured protocols")
+	thresholdBIPS := fs.Uint("threshold-bips", uint(cfg.Finalizer.VoterThresholdBIPS), "Voter threshold in BIPS")
+	addressHex := fs.String("address", "", "Address to report selection for, default is the signing policy address")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *rewardEpochId < 0 {
+		return errors.New("-reward-epoch must be set")
+	}
+	if *thresholdBIPS > 10000 {
+		return fmt.Errorf("invalid voter threshold %d BIPS", *thresholdBIPS)
+	}
+	protocolIds, err := parseProtocolIds(*protocols, cfg.Protocol)
+	if err != nil {
+		return err
+	}
+
+	var address common.Address
+	if *addressHex != "" {
+		if !common.IsHexAddress(*addressHex) {
+			return fmt.Errorf("invalid address %s", *addressHex)
+		}
+		address = common.HexToAddress(*addressHex)
+	} else {
+		pk, err := config.PrivateKeyFromConfig(cfg.Credentials.SigningPolicyPrivateKeyFile, cfg.Credentials.SigningPolicyPrivateKey)
+		if err != nil {
+			return errors.Wrap(err, "error reading signing policy private key, use -address to set the address")
+		}
+		address = crypto.PubkeyToAddress(pk.PublicKey)
+	}
+
+	chainCfg := cfg.ChainConfig()
+	ethClient, err := chainCfg.DialETH()
+	if err != nil {
+		return err
+	}
+	relayContract, err := relay.NewRelay(cfg.ContractAddresses.Relay, ethClient)
+	if err != nil {
+		return errors.Wrap(err, "error creating relay contract")
+	}
+	finalizerContext, err := newFinalizerContext(cfg, relayContract)
+	if err != nil {
+		return err
+	}
+	relayClient, err := NewRelayContractClient(ethClient, cfg.ContractAddresses.Relay, nil, address)
+	if err != nil {
+		return err
+	}
+	relayClient.SetLegacyAddresses(cfg.ContractAddresses.LegacyRelays, finalizerContext.rewardEpochTiming())
+
+	sp, err := fetchSigningPolicy(finalizerDBImpl{client: ctx.DB()}, relayClient, finalizerContext, *rewardEpochId)
+	if err != nil {
+		return err
+	}
+
+	fromRound, toRound := uint32(*from), uint32(*to)
+	if fromRound == 0 {
+		fromRound = sp.startVotingRoundId
+	}
+	if toRound == 0 {
+		toRound = uint32(finalizerContext.rewardEpoch.Start + (sp.rewardEpochId+1)*finalizerContext.rewardEpoch.Period - 1)
+	}
+	if toRound < fromRound {
+		return fmt.Errorf("invalid voting round range %d-%d", fromRound, toRound)
+	}
+
+	sim, err := simulateSelection(sp, protocolIds, fromRound, toRound, uint16(*thresholdBIPS), address)
+	if err != nil {
+		return err
+	}
+	return writeSelectionSimulation(os.Stdout, sim)
+}
+
+// Parses comma separated protocol ids, returns ids of configured protocols if empty
+func parseProtocolIds(s string, configured map[string]clientConfig.ProtocolConfig) ([]byte, error) {
+	var ids []byte
+	if s == "" {
+		for _, protocol := range configured {
+			ids = append(ids, protocol.Id)
+		}
+	} else {
+		for _, part := range strings.Split(s, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid protocol id %s", part)
+			}
+			ids = append(ids, byte(id))
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no protocols configured, use -protocols to set protocol ids")
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// Returns the signing policy of the reward epoch, initialized during the previous reward epoch
+// (or later if initialization was late). The indexer database is checked first, then the chain.
+func fetchSigningPolicy(db finalizerDB, relayClient *relayContractClient, fCtx *finalizerContext, rewardEpochId int64) (*signingPolicy, error) {
+	timing := fCtx.rewardEpochTiming()
+	from := timing.StartTime(rewardEpochId - 1).Unix()
+	to := timing.EndTime(rewardEpochId).Unix()
+	if now := time.Now().Unix(); to > now {
+		to = now
+	}
+
+	spList, err := relayClient.FetchSigningPolicies(db, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, sp := range spList {
+		if sp.policyData.RewardEpochId.Int64() == rewardEpochId {
+			return newSigningPolicy(sp.policyData), nil
+		}
+	}
+
+	policyData, err := relayClient.FetchSigningPolicyFromChain(context.Background(), rewardEpochId, uint64(from), uint64(to))
+	if err != nil {
+		return nil, err
+	}
+	if policyData == nil {
+		return nil, fmt.Errorf("signing policy for reward epoch %d not found", rewardEpochId)
+	}
+	return newSigningPolicy(policyData), nil
+}
+
+func simulateSelection(
+	sp *signingPolicy, protocolIds []byte, fromRound, toRound uint32, thresholdBIPS uint16, address common.Address,
+) (*selectionSimulation, error) {
+	sim := &selectionSimulation{
+		rewardEpochId: sp.rewardEpochId,
+		thresholdBIPS: thresholdBIPS,
+		address:       address,
+	}
+	for votingRoundId := fromRound; votingRoundId <= toRound; votingRoundId++ {
+		for _, protocolId := range protocolIds {
+			selected, err := sp.voters.SelectVoters(sp.seed, protocolId, votingRoundId, thresholdBIPS)
+			if err != nil {
+				return nil, err
+			}
+			addresses := selected.ToSlice()
+			sort.Slice(addresses, func(i, j int) bool {
+				return sp.voters.VoterIndex(addresses[i]) < sp.voters.VoterIndex(addresses[j])
+			})
+			sim.selections = append(sim.selections, voterSelection{
+				votingRoundId: votingRoundId,
+				protocolId:    protocolId,
+				selected:      addresses,
+				ours:          selected.Contains(address),
+			})
+		}
+		if votingRoundId == toRound { // avoid overflow
+			break
+		}
+	}
+	return sim, nil
+}
+
+// Writes selected addresses per voting round and protocol, followed by the selection
+// frequency of the address per protocol
+func writeSelectionSimulation(w io.Writer, sim *selectionSimulation) error {
+	fmt.Fprintf(w, "Reward epoch %d, voter threshold %d BIPS, address %v\n\n", sim.rewardEpochId, sim.thresholdBIPS, sim.address)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VOTING ROUND\tPROTOCOL\tSELECTED\tADDRESSES")
+	type count struct{ selected, total int }
+	counts := make(map[byte]*count)
+	var protocolIds []byte
+	for _, s := range sim.selections {
+		c, ok := counts[s.protocolId]
+		if !ok {
+			c = &count{}
+			counts[s.protocolId] = c
+			protocolIds = append(protocolIds, s.protocolId)
+		}
+		c.total++
+		if s.ours {
+			c.selected++
+		}
+		addresses := make([]string, len(s.selected))
+		for i, address := range s.selected {
+			addresses[i] = address.Hex()
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%t\t%s\n", s.votingRoundId, s.protocolId, s.ours, strings.Join(addresses, ","))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	for _, protocolId := range protocolIds {
+		c := counts[protocolId]
+		fmt.Fprintf(w, "Protocol %d: selected in %d of %d voting rounds (%.2f%%)\n",
+			protocolId, c.selected, c.total, 100*float64(c.selected)/float64(c.total))
+	}
+	return nil
+}
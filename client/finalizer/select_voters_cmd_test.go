@@ -0,0 +1,75 @@
+package finalizer
+
+import (
+	"bytes"
+	"flare-tlc/client/shared/voters"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateSelection(t *testing.T) {
+	addresses := make([]common.Address, 10)
+	weights := make([]uint16, 10)
+	for i := range addresses {
+		addresses[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+		weights[i] = uint16(100 * (i + 1))
+	}
+	sp := &signingPolicy{rewardEpochId: 5, seed: big.NewInt(12345), voters: voters.NewVoterSet(addresses, weights)}
+
+	sim, err := simulateSelection(sp, []byte{100, 200}, 1000, 1009, 1000, addresses[9])
+	require.NoError(t, err)
+	require.Len(t, sim.selections, 20)
+
+	selectedCount := 0
+	for _, s := range sim.selections {
+		expected, err := sp.voters.SelectVoters(sp.seed, s.protocolId, s.votingRoundId, 1000)
+		require.NoError(t, err)
+		require.ElementsMatch(t, expected.ToSlice(), s.selected)
+		require.Equal(t, expected.Contains(addresses[9]), s.ours)
+		for i := 1; i < len(s.selected); i++ {
+			require.Less(t, sp.voters.VoterIndex(s.selected[i-1]), sp.voters.VoterIndex(s.selected[i]))
+		}
+		if s.ours && s.protocolId == 100 {
+			selectedCount++
+		}
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeSelectionSimulation(&out, sim))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Contains(t, lines[len(lines)-2], "Protocol 100: selected in "+strconv.Itoa(selectedCount)+" of 10 voting rounds")
+	require.True(t, strings.HasPrefix(lines[len(lines)-1], "Protocol 200:"))
+
+	_, err = simulateSelection(sp, []byte{100}, 1000, 1000, 6000, addresses[0])
+	require.Error(t, err)
+}
+
+func TestFetchSigningPolicy(t *testing.T) {
+	clients, err := setupTest()
+	require.NoError(t, err)
+	c := clients.finalizer
+
+	sp, err := fetchSigningPolicy(c.db, c.relayClient, c.finalizerContext, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), sp.rewardEpochId)
+	require.Equal(t, 1, sp.voters.Count())
+
+	_, err = fetchSigningPolicy(c.db, c.relayClient, c.finalizerContext, 2)
+	require.ErrorContains(t, err, "not found")
+}
+
+func TestParseProtocolIds(t *testing.T) {
+	ids, err := parseProtocolIds("200, 100", nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{100, 200}, ids)
+
+	_, err = parseProtocolIds("300", nil)
+	require.Error(t, err)
+	_, err = parseProtocolIds("", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,209 @@
+package finalizer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flare-tlc/client/shared"
+	"flare-tlc/logger"
+	"flare-tlc/utils"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const signatureStatsAPIPath = "finalizer/signature-stats"
+
+var (
+	voterRoundsSigned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "finalizer",
+		Name:      "voter_rounds_signed",
+		Help:      "Number of voting rounds signed by the voter in the last completed reward epoch",
+	}, []string{"protocol", "voter"})
+	voterRoundsAgreed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "finalizer",
+		Name:      "voter_rounds_agreed",
+		Help:      "Number of voting rounds in which the voter signed the message that reached the threshold in the last completed reward epoch",
+	}, []string{"protocol", "voter"})
+	voterSignatureDelay = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "finalizer",
+		Name:      "voter_signature_delay_seconds",
+		Help:      "Average signature delay from the start of the signing voting round in the last completed reward epoch",
+	}, []string{"protocol", "voter"})
+)
+
+var signatureStatsCSVHeader = []string{
+	"rewardEpochId", "protocolId", "voter", "weight", "rounds", "roundsSigned",
+	"roundsAgreed", "equivocations", "avgDelaySeconds", "maxDelaySeconds",
+}
+
+// Signature statistics of a voter for a protocol in a reward epoch
+type voterSignatureStats struct {
+	RewardEpochId   int64          `json:"rewardEpochId"`
+	ProtocolId      byte           `json:"protocolId"`
+	Voter           common.Address `json:"voter"`
+	Weight          uint16         `json:"weight"`
+	Rounds          int            `json:"rounds"`       // voting rounds with at least one signature for the protocol
+	RoundsSigned    int            `json:"roundsSigned"` // voting rounds signed by the voter
+	RoundsAgreed    int            `json:"roundsAgreed"` // voting rounds in which the voter signed the message that reached the threshold
+	Equivocations   int            `json:"equivocations"`
+	AvgDelaySeconds float64        `json:"avgDelaySeconds"`
+	MaxDelaySeconds int64          `json:"maxDelaySeconds"`
+}
+
+type voterStatsKey struct {
+	protocolId byte
+	voterIndex int
+}
+
+// Computes signature statistics of all voters in the reward epoch for all protocols with
+// stored signatures. Signatures for a voting round are submitted in the following voting
+// round, the delay is measured from its start.
+func (s *submissionStorage) SignatureStats(rewardEpochId int64, votingEpoch *utils.Epoch) []voterSignatureStats {
+	s.Lock()
+	defer s.Unlock()
+
+	var sp *signingPolicy
+	rounds := make(map[byte]int)
+	equivocations := make(map[voterStatsKey]int)
+	delaySums := make(map[voterStatsKey]int64)
+	maxDelays := make(map[voterStatsKey]int64)
+	signedRounds := make(map[voterStatsKey]map[uint32]bool)
+	agreedRounds := make(map[voterStatsKey]int)
+
+	for votingRoundId, vrItem := range s.vrMap {
+		signingStart := votingEpoch.StartTime(int64(votingRoundId) + 1).Unix()
+		roundProtocols := make(map[byte]bool)
+		for key, message := range vrItem.msgMap {
+			if message.signingPolicy.rewardEpochId != rewardEpochId {
+				continue
+			}
+			sp = message.signingPolicy
+			roundProtocols[key.protocolId] = true
+
+			for _, payload := range message.payload {
+				if payload == nil {
+					continue
+				}
+				sk := voterStatsKey{protocolId: key.protocolId, voterIndex: payload.index}
+				if message.thresholdReached {
+					agreedRounds[sk]++
+				}
+				if signedRounds[sk] == nil {
+					signedRounds[sk] = make(map[uint32]bool)
+				}
+				if signedRounds[sk][votingRoundId] {
+					continue
+				}
+				signedRounds[sk][votingRoundId] = true
+
+				delay := utils.Max(payload.timestamp-signingStart, 0)
+				delaySums[sk] += delay
+				maxDelays[sk] = utils.Max(maxDelays[sk], delay)
+			}
+		}
+		for protocolId := range roundProtocols {
+			rounds[protocolId]++
+		}
+		for key, e := range vrItem.equivocators {
+			if sp == nil || e.RewardEpochId != rewardEpochId {
+				continue
+			}
+			if voterIndex := sp.voters.VoterIndex(key.signer); voterIndex >= 0 {
+				equivocations[voterStatsKey{protocolId: key.protocolId, voterIndex: voterIndex}]++
+			}
+		}
+	}
+	if sp == nil {
+		return nil
+	}
+
+	result := make([]voterSignatureStats, 0, len(rounds)*sp.voters.Count())
+	for protocolId, protocolRounds := range rounds {
+		for i := 0; i < sp.voters.Count(); i++ {
+			sk := voterStatsKey{protocolId: protocolId, voterIndex: i}
+			vs := voterSignatureStats{
+				RewardEpochId:   rewardEpochId,
+				ProtocolId:      protocolId,
+				Voter:           sp.voters.VoterAddress(i),
+				Weight:          sp.voters.VoterWeight(i),
+				Rounds:          protocolRounds,
+				RoundsSigned:    len(signedRounds[sk]),
+				RoundsAgreed:    agreedRounds[sk],
+				Equivocations:   equivocations[sk],
+				MaxDelaySeconds: maxDelays[sk],
+			}
+			if vs.RoundsSigned > 0 {
+				vs.AvgDelaySeconds = float64(delaySums[sk]) / float64(vs.RoundsSigned)
+			}
+			result = append(result, vs)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ProtocolId != result[j].ProtocolId {
+			return result[i].ProtocolId < result[j].ProtocolId
+		}
+		return bytes.Compare(result[i].Voter[:], result[j].Voter[:]) < 0
+	})
+	return result
+}
+
+// Sets per voter metrics, metrics of voters not in stats are removed
+func setSignatureStatsMetrics(stats []voterSignatureStats) {
+	voterRoundsSigned.Reset()
+	voterRoundsAgreed.Reset()
+	voterSignatureDelay.Reset()
+	for _, vs := range stats {
+		protocol := strconv.Itoa(int(vs.ProtocolId))
+		voter := vs.Voter.Hex()
+		voterRoundsSigned.WithLabelValues(protocol, voter).Set(float64(vs.RoundsSigned))
+		voterRoundsAgreed.WithLabelValues(protocol, voter).Set(float64(vs.RoundsAgreed))
+		voterSignatureDelay.WithLabelValues(protocol, voter).Set(vs.AvgDelaySeconds)
+	}
+}
+
+func writeSignatureStatsCSV(w http.ResponseWriter, stats []voterSignatureStats) {
+	w.Header().Set("Content-Type", "text/csv")
+	cw := csv.NewWriter(w)
+	records := [][]string{signatureStatsCSVHeader}
+	for _, vs := range stats {
+		records = append(records, []string{
+			strconv.FormatInt(vs.RewardEpochId, 10),
+			strconv.Itoa(int(vs.ProtocolId)),
+			vs.Voter.Hex(),
+			strconv.Itoa(int(vs.Weight)),
+			strconv.Itoa(vs.Rounds),
+			strconv.Itoa(vs.RoundsSigned),
+			strconv.Itoa(vs.RoundsAgreed),
+			strconv.Itoa(vs.Equivocations),
+			strconv.FormatFloat(vs.AvgDelaySeconds, 'f', 2, 64),
+			strconv.FormatInt(vs.MaxDelaySeconds, 10),
+		})
+	}
+	if err := cw.WriteAll(records); err != nil {
+		logger.Error("Error writing signature statistics: %v", err)
+	}
+}
+
+// Registers handler for /api/finalizer/signature-stats?rewardEpochId=<id>[&format=csv]
+func registerSignatureStatsAPIHandler(s *submissionStorage, votingEpoch *utils.Epoch) {
+	shared.RegisterAPIHandler(signatureStatsAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		rewardEpochId, err := strconv.ParseInt(r.URL.Query().Get("rewardEpochId"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid or missing rewardEpochId parameter", http.StatusBadRequest)
+			return
+		}
+		stats := s.SignatureStats(rewardEpochId, votingEpoch)
+		switch r.URL.Query().Get("format") {
+		case "", "json":
+			shared.WriteJSONResponse(w, stats)
+		case "csv":
+			writeSignatureStatsCSV(w, stats)
+		default:
+			http.Error(w, "invalid format parameter, expected json or csv", http.StatusBadRequest)
+		}
+	})
+}
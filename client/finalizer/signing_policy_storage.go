@@ -5,11 +5,14 @@ import (
 	"cmp"
 	"flare-tlc/client/shared"
 	"flare-tlc/client/shared/voters"
+	"flare-tlc/utils"
 	"flare-tlc/utils/contracts/relay"
 	"fmt"
 	"math/big"
 	"sort"
 	"sync"
+
+	"golang.org/x/exp/slices"
 )
 
 // Duplicates relay.RelaySigningPolicyInitialized but with different fields and
@@ -42,60 +45,125 @@ func newSigningPolicy(r *relay.RelaySigningPolicyInitialized) *signingPolicy {
 type signingPolicyStorage struct {
 
 	// sorted list of signing policies, sorted by rewardEpochId (and also by startVotingRoundId)
+	// the list may contain gaps if signing policies were not received in order
 	spList []*signingPolicy
 
+	// expected reward epoch boundaries, used to attribute voting rounds when the
+	// signing policy of the next reward epoch is missing, may be nil
+	rewardEpoch *utils.IntEpoch
+
 	// mutex
 	sync.Mutex
 }
 
-func newSigningPolicyStorage() *signingPolicyStorage {
+// Missing signing policies between two stored ones
+type signingPolicyGap struct {
+	fromRewardEpochId int64
+	toRewardEpochId   int64 // inclusive
+
+	// block timestamps of the stored signing policies around the gap
+	fromTimestamp uint64
+	toTimestamp   uint64
+}
+
+func newSigningPolicyStorage(rewardEpoch *utils.IntEpoch) *signingPolicyStorage {
 	return &signingPolicyStorage{
-		spList: make([]*signingPolicy, 0, 10),
+		spList:      make([]*signingPolicy, 0, 10),
+		rewardEpoch: rewardEpoch,
 	}
 }
 
 // Does not lock the structure, should be called from a function that does lock.
 // We assume that the list is sorted by rewardEpochId and also by startVotingRoundId.
-func (s *signingPolicyStorage) findByVotingRoundId(votingRoundId uint32) *signingPolicy {
+// Returns nil and true if the voting round may belong to a reward epoch with a missing signing policy.
+func (s *signingPolicyStorage) findByVotingRoundId(votingRoundId uint32) (*signingPolicy, bool) {
 	i, found := sort.Find(len(s.spList), func(i int) int {
 		return cmp.Compare(votingRoundId, s.spList[i].startVotingRoundId)
 	})
 	if found {
-		return s.spList[i]
+		return s.spList[i], false
 	}
 	if i == 0 {
-		return nil
+		return nil, false
+	}
+	sp := s.spList[i-1]
+	if i < len(s.spList) && s.spList[i].rewardEpochId != sp.rewardEpochId+1 && !s.beforeExpectedEnd(sp, votingRoundId) {
+		return nil, true
 	}
-	return s.spList[i-1]
+	return sp, false
 }
 
+// Returns true if the voting round is before the earliest possible start of the next reward epoch
+func (s *signingPolicyStorage) beforeExpectedEnd(sp *signingPolicy, votingRoundId uint32) bool {
+	if s.rewardEpoch == nil {
+		return false
+	}
+	return int64(votingRoundId) < s.rewardEpoch.Start+(sp.rewardEpochId+1)*s.rewardEpoch.Period
+}
+
+// Adds the signing policy, policies may be added in any order. Adding an already stored
+// policy has no effect.
 func (s *signingPolicyStorage) Add(sp *signingPolicy) error {
 	s.Lock()
 	defer s.Unlock()
 
-	if len(s.spList) > 0 {
-		// check consistency, previous epoch should be already added
-		if s.spList[len(s.spList)-1].rewardEpochId != sp.rewardEpochId-1 {
-			return fmt.Errorf("missing signing policy for reward epoch id %d", sp.rewardEpochId-1)
-		}
-		// should be sorted by voting round id, should not happen
-		if sp.startVotingRoundId < s.spList[len(s.spList)-1].startVotingRoundId {
-			return fmt.Errorf("signing policy for reward epoch id %d has larger start voting round id than previous policy",
-				sp.rewardEpochId)
-		}
+	i, found := sort.Find(len(s.spList), func(i int) int {
+		return cmp.Compare(sp.rewardEpochId, s.spList[i].rewardEpochId)
+	})
+	if found {
+		return nil
+	}
+	// should be sorted by voting round id, should not happen
+	if i > 0 && sp.startVotingRoundId < s.spList[i-1].startVotingRoundId {
+		return fmt.Errorf("signing policy for reward epoch id %d has smaller start voting round id than previous policy",
+			sp.rewardEpochId)
+	}
+	if i < len(s.spList) && sp.startVotingRoundId > s.spList[i].startVotingRoundId {
+		return fmt.Errorf("signing policy for reward epoch id %d has larger start voting round id than next policy",
+			sp.rewardEpochId)
 	}
 
-	s.spList = append(s.spList, sp)
+	s.spList = slices.Insert(s.spList, i, sp)
 	return nil
 }
 
+// Returns gaps in stored signing policies, ordered by reward epoch id
+func (s *signingPolicyStorage) Gaps() []signingPolicyGap {
+	s.Lock()
+	defer s.Unlock()
+
+	var gaps []signingPolicyGap
+	for i := 1; i < len(s.spList); i++ {
+		prev, next := s.spList[i-1], s.spList[i]
+		if next.rewardEpochId == prev.rewardEpochId+1 {
+			continue
+		}
+		gaps = append(gaps, signingPolicyGap{
+			fromRewardEpochId: prev.rewardEpochId + 1,
+			toRewardEpochId:   next.rewardEpochId - 1,
+			fromTimestamp:     prev.blockTimestamp,
+			toTimestamp:       next.blockTimestamp,
+		})
+	}
+	return gaps
+}
+
+// Returns true if the voting round may belong to a reward epoch with a missing signing policy
+func (s *signingPolicyStorage) InGap(votingRoundId uint32) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	_, inGap := s.findByVotingRoundId(votingRoundId)
+	return inGap
+}
+
 // Return the signing policy for the voting round, or nil if not found.
 // Also returns true if the policy is the last one or false otherwise.
 func (s *signingPolicyStorage) GetForVotingRound(votingRoundId uint32) (*signingPolicy, bool) {
 	s.Lock()
 	defer s.Unlock()
 
-	sp := s.findByVotingRoundId(votingRoundId)
+	sp, _ := s.findByVotingRoundId(votingRoundId)
 	if sp == nil {
 		return nil, false
 	}
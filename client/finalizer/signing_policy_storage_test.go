@@ -0,0 +1,57 @@
+package finalizer
+
+import (
+	"flare-tlc/utils"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningPolicyStorageGaps(t *testing.T) {
+	// reward epochs are expected to start every 100 voting rounds, starting with voting round 1000
+	s := newSigningPolicyStorage(utils.NewIntEpoch(1000, 100))
+	newPolicy := func(rewardEpochId int64, startVotingRoundId uint32) *signingPolicy {
+		return &signingPolicy{
+			rewardEpochId:      rewardEpochId,
+			startVotingRoundId: startVotingRoundId,
+			blockTimestamp:     uint64(rewardEpochId) * 1000,
+		}
+	}
+
+	require.NoError(t, s.Add(newPolicy(1, 1100)))
+	require.NoError(t, s.Add(newPolicy(4, 1405)))
+	require.Equal(t, []signingPolicyGap{
+		{fromRewardEpochId: 2, toRewardEpochId: 3, fromTimestamp: 1000, toTimestamp: 4000},
+	}, s.Gaps())
+
+	// voting rounds before the expected start of the missing reward epoch belong to the known one
+	sp, last := s.GetForVotingRound(1150)
+	require.NotNil(t, sp)
+	require.Equal(t, int64(1), sp.rewardEpochId)
+	require.False(t, last)
+	require.False(t, s.InGap(1150))
+
+	// voting rounds that may belong to missing reward epochs
+	sp, _ = s.GetForVotingRound(1200)
+	require.Nil(t, sp)
+	require.True(t, s.InGap(1200))
+	require.True(t, s.InGap(1404))
+
+	sp, last = s.GetForVotingRound(1405)
+	require.Equal(t, int64(4), sp.rewardEpochId)
+	require.True(t, last)
+
+	// backfill out of order, duplicates are ignored
+	require.NoError(t, s.Add(newPolicy(3, 1300)))
+	require.NoError(t, s.Add(newPolicy(3, 1300)))
+	require.Equal(t, []signingPolicyGap{
+		{fromRewardEpochId: 2, toRewardEpochId: 2, fromTimestamp: 1000, toTimestamp: 3000},
+	}, s.Gaps())
+	require.Error(t, s.Add(newPolicy(2, 1350)))
+	require.NoError(t, s.Add(newPolicy(2, 1210)))
+	require.Empty(t, s.Gaps())
+
+	sp, _ = s.GetForVotingRound(1250)
+	require.Equal(t, int64(2), sp.rewardEpochId)
+	require.False(t, s.InGap(1250))
+}
@@ -1,10 +1,14 @@
 package finalizer
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/exp/slices"
 )
 
 type messageData struct {
@@ -23,8 +27,18 @@ type votingRoundKey struct {
 	messageHash common.Hash
 }
 
+type voterKey struct {
+	protocolId byte
+	signer     common.Address
+}
+
 type votingRoundItem struct {
 	msgMap map[votingRoundKey]*messageData
+
+	// Message hashes signed by each voter for each protocol, used to detect equivocation
+	signedHashes map[voterKey][]common.Hash
+	// Equivocating voters, the conflicting hashes are in signedHashes
+	equivocators map[voterKey]*equivocation
 }
 
 type submissionStorage struct {
@@ -32,13 +46,17 @@ type submissionStorage struct {
 	// We use two maps instead of one to make it easier to remove a voting round
 	vrMap map[uint32]*votingRoundItem
 
+	// If true, signatures of equivocating voters are removed and not accepted anymore
+	excludeEquivocators bool
+
 	// mutex
 	sync.Mutex
 }
 
 type addPayloadResult struct {
 	message          *messageData
-	thresholdReached bool // true if the threshold was reached after adding this payload (and was below before)
+	thresholdReached bool          // true if the threshold was reached after adding this payload (and was below before)
+	equivocation     *equivocation // set if this payload revealed a new equivocation of the signer
 }
 
 func newMessageData(sp *signingPolicy) *messageData {
@@ -66,9 +84,69 @@ func (m *messageData) addPayload(p *signedPayload, threshold uint16) error {
 	return nil
 }
 
-func newSubmissionStorage() *submissionStorage {
+// Removes the payload of the voter, the threshold is re-evaluated so that the message
+// is reported again once enough weight is collected without the voter
+func (m *messageData) removePayload(voterIndex int, threshold uint16) {
+	if voterIndex < 0 || m.payload[voterIndex] == nil {
+		return
+	}
+	m.payload[voterIndex] = nil
+	m.weight -= m.signingPolicy.voters.VoterWeight(voterIndex)
+	m.thresholdReached = m.weight > threshold
+}
+
+func newSubmissionStorage(excludeEquivocators bool) *submissionStorage {
 	return &submissionStorage{
-		vrMap: make(map[uint32]*votingRoundItem),
+		vrMap:               make(map[uint32]*votingRoundItem),
+		excludeEquivocators: excludeEquivocators,
+	}
+}
+
+func newVotingRoundItem() *votingRoundItem {
+	return &votingRoundItem{
+		msgMap:       make(map[votingRoundKey]*messageData),
+		signedHashes: make(map[voterKey][]common.Hash),
+		equivocators: make(map[voterKey]*equivocation),
+	}
+}
+
+// Records the message hash signed by the voter, returns a new equivocation if the voter
+// already signed a different message hash for the same protocol in the voting round
+func (v *votingRoundItem) recordSignedHash(p *signedPayload, sp *signingPolicy) *equivocation {
+	key := voterKey{protocolId: p.message.protocolId, signer: p.signer}
+	hashes := v.signedHashes[key]
+	if slices.Contains(hashes, p.messageHash) {
+		return nil
+	}
+	hashes = append(hashes, p.messageHash)
+	v.signedHashes[key] = hashes
+	if len(hashes) < 2 {
+		return nil
+	}
+
+	if e, ok := v.equivocators[key]; ok {
+		e.MessageHashes = append(e.MessageHashes, p.messageHash)
+		return nil
+	}
+	e := &equivocation{
+		RewardEpochId: sp.rewardEpochId,
+		VotingRoundId: p.message.votingRoundId,
+		ProtocolId:    p.message.protocolId,
+		Voter:         p.signer,
+		MessageHashes: slices.Clone(hashes),
+		Timestamp:     time.Now(),
+	}
+	v.equivocators[key] = e
+	return e
+}
+
+// Removes payloads of the voter for the protocol from all messages in the voting round
+func (v *votingRoundItem) excludeVoter(protocolId byte, sp *signingPolicy, signer common.Address, threshold uint16) {
+	voterIndex := sp.voters.VoterIndex(signer)
+	for key, message := range v.msgMap {
+		if key.protocolId == protocolId {
+			message.removePayload(voterIndex, threshold)
+		}
 	}
 }
 
@@ -81,12 +159,23 @@ func (s *submissionStorage) Add(p *signedPayload, sp *signingPolicy, threshold u
 
 	vrItem, ok := s.vrMap[p.message.votingRoundId]
 	if !ok {
-		vrItem = &votingRoundItem{
-			msgMap: make(map[votingRoundKey]*messageData),
-		}
+		vrItem = newVotingRoundItem()
 		s.vrMap[p.message.votingRoundId] = vrItem
 	}
 
+	if sp.voters.VoterIndex(p.signer) < 0 {
+		return addPayloadResult{}, fmt.Errorf("signer %s is not a registered voter in the current reward epoch", p.signer.Hex())
+	}
+	newEquivocation := vrItem.recordSignedHash(p, sp)
+	if s.excludeEquivocators {
+		if newEquivocation != nil {
+			vrItem.excludeVoter(p.message.protocolId, sp, p.signer, threshold)
+		}
+		if _, ok := vrItem.equivocators[voterKey{protocolId: p.message.protocolId, signer: p.signer}]; ok {
+			return addPayloadResult{equivocation: newEquivocation}, nil
+		}
+	}
+
 	key := votingRoundKey{
 		protocolId:  p.message.protocolId,
 		messageHash: p.messageHash,
@@ -106,9 +195,35 @@ func (s *submissionStorage) Add(p *signedPayload, sp *signingPolicy, threshold u
 	return addPayloadResult{
 		message:          message,
 		thresholdReached: !thresholdAlreadyReached && message.thresholdReached,
+		equivocation:     newEquivocation,
 	}, nil
 }
 
+// Returns all equivocations in stored voting rounds, ordered by voting round and protocol id
+func (s *submissionStorage) Equivocations() []equivocation {
+	s.Lock()
+	defer s.Unlock()
+
+	var result []equivocation
+	for _, vrItem := range s.vrMap {
+		for _, e := range vrItem.equivocators {
+			e := *e
+			e.MessageHashes = slices.Clone(e.MessageHashes)
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].VotingRoundId != result[j].VotingRoundId {
+			return result[i].VotingRoundId < result[j].VotingRoundId
+		}
+		if result[i].ProtocolId != result[j].ProtocolId {
+			return result[i].ProtocolId < result[j].ProtocolId
+		}
+		return bytes.Compare(result[i].Voter[:], result[j].Voter[:]) < 0
+	})
+	return result
+}
+
 func (s *submissionStorage) Get(
 	votingRoundId uint32,
 	protocolId byte,
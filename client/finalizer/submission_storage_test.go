@@ -0,0 +1,121 @@
+package finalizer
+
+import (
+	"flare-tlc/client/shared/voters"
+	"flare-tlc/utils"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmissionStorageEquivocation(t *testing.T) {
+	voterAddresses := []common.Address{
+		common.HexToAddress("0x01"),
+		common.HexToAddress("0x02"),
+		common.HexToAddress("0x03"),
+	}
+	sp := &signingPolicy{
+		rewardEpochId: 1,
+		threshold:     50,
+		voters:        voters.NewVoterSet(voterAddresses, []uint16{40, 40, 20}),
+	}
+	hashA := common.HexToHash("0xaa")
+	hashB := common.HexToHash("0xbb")
+	payload := func(signer common.Address, hash common.Hash) *signedPayload {
+		return &signedPayload{
+			message:     &submittedPayload{protocolId: 100, votingRoundId: 10, merkleRoot: hash[:]},
+			signer:      signer,
+			messageHash: hash,
+		}
+	}
+
+	for _, excludeEquivocators := range []bool{false, true} {
+		s := newSubmissionStorage(excludeEquivocators)
+
+		res, err := s.Add(payload(voterAddresses[0], hashA), sp, sp.threshold)
+		require.NoError(t, err)
+		require.Nil(t, res.equivocation)
+		res, err = s.Add(payload(voterAddresses[1], hashA), sp, sp.threshold)
+		require.NoError(t, err)
+		require.True(t, res.thresholdReached)
+
+		// voter 0 also signs a different hash
+		res, err = s.Add(payload(voterAddresses[0], hashB), sp, sp.threshold)
+		require.NoError(t, err)
+		require.NotNil(t, res.equivocation)
+		require.Equal(t, voterAddresses[0], res.equivocation.Voter)
+		require.Equal(t, []common.Hash{hashA, hashB}, res.equivocation.MessageHashes)
+
+		// duplicates are not reported again
+		res, err = s.Add(payload(voterAddresses[0], hashB), sp, sp.threshold)
+		require.NoError(t, err)
+		require.Nil(t, res.equivocation)
+
+		equivocations := s.Equivocations()
+		require.Len(t, equivocations, 1)
+		require.Equal(t, uint32(10), equivocations[0].VotingRoundId)
+		require.Equal(t, byte(100), equivocations[0].ProtocolId)
+
+		data := s.Get(10, 100, hashA)
+		if excludeEquivocators {
+			require.Nil(t, data.payload[0])
+			require.Equal(t, uint16(40), data.weight)
+			require.Nil(t, s.Get(10, 100, hashB))
+
+			// threshold is reached again without the equivocator
+			res, err = s.Add(payload(voterAddresses[2], hashA), sp, sp.threshold)
+			require.NoError(t, err)
+			require.True(t, res.thresholdReached)
+		} else {
+			require.NotNil(t, data.payload[0])
+			require.Equal(t, uint16(80), data.weight)
+			require.NotNil(t, s.Get(10, 100, hashB))
+		}
+	}
+}
+
+func TestSubmissionStorageSignatureStats(t *testing.T) {
+	voterAddresses := []common.Address{
+		common.HexToAddress("0x01"),
+		common.HexToAddress("0x02"),
+	}
+	sp := &signingPolicy{
+		rewardEpochId: 1,
+		threshold:     50,
+		voters:        voters.NewVoterSet(voterAddresses, []uint16{60, 40}),
+	}
+	votingEpoch := utils.NewEpoch(time.Unix(1000, 0), 90*time.Second)
+	payload := func(signer common.Address, votingRoundId uint32, hash common.Hash, delay int64) *signedPayload {
+		return &signedPayload{
+			message:     &submittedPayload{protocolId: 100, votingRoundId: votingRoundId, merkleRoot: hash[:]},
+			signer:      signer,
+			messageHash: hash,
+			timestamp:   votingEpoch.StartTime(int64(votingRoundId)+1).Unix() + delay,
+		}
+	}
+
+	s := newSubmissionStorage(false)
+	for _, p := range []*signedPayload{
+		payload(voterAddresses[0], 10, common.HexToHash("0xaa"), 10),
+		payload(voterAddresses[1], 10, common.HexToHash("0xbb"), 20),
+		payload(voterAddresses[0], 11, common.HexToHash("0xcc"), 30),
+	} {
+		_, err := s.Add(p, sp, sp.threshold)
+		require.NoError(t, err)
+	}
+
+	stats := s.SignatureStats(1, votingEpoch)
+	require.Len(t, stats, 2)
+	require.Equal(t, voterSignatureStats{
+		RewardEpochId: 1, ProtocolId: 100, Voter: voterAddresses[0], Weight: 60,
+		Rounds: 2, RoundsSigned: 2, RoundsAgreed: 2, AvgDelaySeconds: 20, MaxDelaySeconds: 30,
+	}, stats[0])
+	require.Equal(t, voterSignatureStats{
+		RewardEpochId: 1, ProtocolId: 100, Voter: voterAddresses[1], Weight: 40,
+		Rounds: 2, RoundsSigned: 1, RoundsAgreed: 0, AvgDelaySeconds: 20, MaxDelaySeconds: 20,
+	}, stats[1])
+
+	require.Empty(t, s.SignatureStats(2, votingEpoch))
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/hex"
 	"flare-tlc/client/shared"
+	"flare-tlc/config"
+	"flare-tlc/database"
 	"flare-tlc/logger"
+	"flare-tlc/utils"
 	"flare-tlc/utils/contracts/submission"
+	"sort"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -13,6 +17,11 @@ import (
 
 type submissionContractClient struct {
 	address common.Address
+
+	// address and legacy addresses transactions are read from, rewardEpoch is
+	// used to determine until when legacy contracts are queried
+	history     *shared.ContractHistory
+	rewardEpoch *utils.Epoch
 }
 
 type submissionListenerResponse struct {
@@ -29,7 +38,33 @@ type submitterItemProcessor interface {
 func NewSubmissionContractClient(address common.Address) *submissionContractClient {
 	return &submissionContractClient{
 		address: address,
+		history: shared.NewContractHistory(address, nil),
+	}
+}
+
+// Sets previous submission contract deployments, their submitSignatures txs are also read
+func (s *submissionContractClient) SetLegacyAddresses(legacy []config.LegacyContractAddress, rewardEpoch *utils.Epoch) {
+	s.history = shared.NewContractHistory(s.address, legacy)
+	s.rewardEpoch = rewardEpoch
+}
+
+// Returns txs in the time range (from, to] sent to the contract and its active legacy deployments,
+// ordered by timestamp
+func (s *submissionContractClient) fetchTransactions(
+	db finalizerDB, selector []byte, from, to int64,
+) ([]database.Transaction, error) {
+	var txs []database.Transaction
+	for _, address := range s.history.Addresses(time.Unix(from, 0), s.rewardEpoch) {
+		addressTxs, err := db.FetchTransactionsByAddressAndSelector(address, selector, from, to)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, addressTxs...)
 	}
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].Timestamp < txs[j].Timestamp
+	})
+	return txs, nil
 }
 
 func (s *submissionContractClient) SubmissionTxListener(
@@ -57,21 +92,13 @@ func (s *submissionContractClient) SubmissionTxListener(
 			return ctx.Err()
 		}
 		now := time.Now().Unix()
-		txs, err := db.FetchTransactionsByAddressAndSelector(s.address, selector, eventRangeStart, now)
+		txs, err := s.fetchTransactions(db, selector, eventRangeStart, now)
 		if err != nil {
 			logger.Error("Error fetching transactions %v", err)
 			continue
 		}
 		for _, tx := range txs {
-			inputBytes, err := hex.DecodeString(tx.Input)
-			if err != nil {
-				logger.Info("Invalid submitSignatures tx sent by %s: %v, skipping", tx.FromAddress, err)
-			}
-			payload, err := DecodeSubmitterPayload(inputBytes)
-			if err != nil {
-				// if input cannot be decoded, it is not a valid submission and should be skipped
-				logger.Info("Invalid submitSignatures payload sent by %s: %v, skipping", tx.FromAddress, err)
-			}
+			payload := decodeSubmission(&tx)
 			if len(payload) > 0 {
 				err = processor.ProcessSubmissionData(submissionListenerResponse{
 					payload:   payload,
@@ -90,3 +117,48 @@ func (s *submissionContractClient) SubmissionTxListener(
 		}
 	}
 }
+
+// Returns submitted payloads of the submitSignatures tx, nil if the input is invalid
+func decodeSubmission(tx *database.Transaction) []*submitterPayloadItem {
+	inputBytes, err := hex.DecodeString(tx.Input)
+	if err != nil {
+		logger.Info("Invalid submitSignatures tx sent by %s: %v, skipping", tx.FromAddress, err)
+		return nil
+	}
+	payload, err := DecodeSubmitterPayload(inputBytes)
+	if err != nil {
+		// if input cannot be decoded, it is not a valid submission and should be skipped
+		logger.Info("Invalid submitSignatures payload sent by %s: %v, skipping", tx.FromAddress, err)
+		return nil
+	}
+	return payload
+}
+
+// Processes submissions sent in the time range (from, to] again, used for voting rounds whose
+// signing policy was missing when the submissions were first read
+func (s *submissionContractClient) ReprocessSubmissions(
+	db finalizerDB, from, to int64, processor submitterItemProcessor,
+) error {
+	submissionABI, err := submission.SubmissionMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	txs, err := s.fetchTransactions(db, submissionABI.Methods["submitSignatures"].ID, from, to)
+	if err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		payload := decodeSubmission(&tx)
+		if len(payload) == 0 {
+			continue
+		}
+		err := processor.ProcessSubmissionData(submissionListenerResponse{
+			payload:   payload,
+			timestamp: int64(tx.Timestamp),
+		})
+		if err != nil {
+			logger.Warn("Error reprocessing submitSignatures payload sent by %s: %v", tx.FromAddress, err)
+		}
+	}
+	return nil
+}
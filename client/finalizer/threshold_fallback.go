@@ -0,0 +1,76 @@
+package finalizer
+
+import (
+	"context"
+	"flare-tlc/client/config"
+	"flare-tlc/logger"
+	"flare-tlc/utils"
+
+	"github.com/pkg/errors"
+)
+
+// Determines the threshold for messages signed by the last known signing policy when
+// the signing policy of the next reward epoch is late, see config.ThresholdFallbackConfig
+type thresholdFallback struct {
+	mode              string
+	thresholdBIPS     uint16
+	afterVotingRounds uint32
+
+	// expected reward epoch boundaries in voting rounds
+	rewardEpoch *utils.IntEpoch
+
+	// relay mode only: relay contract parameters, loaded by LoadRelayRules
+	relayRules *relayStateData
+}
+
+func newThresholdFallback(cfg *config.ThresholdFallbackConfig, rewardEpoch *utils.IntEpoch) *thresholdFallback {
+	return &thresholdFallback{
+		mode:              cfg.Mode,
+		thresholdBIPS:     cfg.ThresholdBIPS,
+		afterVotingRounds: cfg.AfterVotingRounds,
+		rewardEpoch:       rewardEpoch,
+	}
+}
+
+// Loads the threshold increase rules from the relay contract, needed in relay mode only
+func (f *thresholdFallback) LoadRelayRules(ctx context.Context, relayClient *relayContractClient) error {
+	if f.mode != config.ThresholdFallbackRelay {
+		return nil
+	}
+	sd, err := relayClient.ethClient.RelayStateData(ctx, relayClient.address)
+	if err != nil {
+		return errors.Wrap(err, "error fetching relay threshold rules")
+	}
+	f.relayRules = sd
+	logger.Info("Threshold fallback follows the relay contract, threshold increase %d BIPS", sd.thresholdIncreaseBIPS)
+	return nil
+}
+
+// Returns the threshold for the voting round signed by the signing policy, last is true
+// if no later signing policy is known
+func (f *thresholdFallback) threshold(sp *signingPolicy, votingRoundId uint32, last bool) uint16 {
+	if !last {
+		return sp.threshold
+	}
+	switch f.mode {
+	case config.ThresholdFallbackRelay:
+		if f.relayRules == nil {
+			return sp.threshold
+		}
+		// the relay contract increases the threshold for its last initialized signing policy,
+		// which is the last known policy here
+		rules := *f.relayRules
+		rules.lastInitializedRewardEpoch = uint32(sp.rewardEpochId)
+		return rules.threshold(sp, votingRoundId)
+	case config.ThresholdFallbackFixed:
+		start := f.rewardEpoch.Start + (sp.rewardEpochId+1)*f.rewardEpoch.Period + int64(f.afterVotingRounds)
+		if int64(votingRoundId) < start {
+			return sp.threshold
+		}
+		// the relay contract never accepts less than the signing policy threshold
+		fixed := uint16(uint64(sp.voters.TotalWeight()) * uint64(f.thresholdBIPS) / 10000)
+		return utils.Max(sp.threshold, fixed)
+	default:
+		return sp.threshold
+	}
+}
@@ -0,0 +1,110 @@
+package finalizer
+
+import (
+	"context"
+	"flare-tlc/client/config"
+	"flare-tlc/client/shared/voters"
+	"flare-tlc/utils"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Reward epoch 5 is the last one with an initialized signing policy, reward epoch 6
+// was expected to start in voting round 1600
+func stalledSigningPolicyClient(t *testing.T, cfg config.ThresholdFallbackConfig) *finalizerClient {
+	clients, err := setupTest()
+	require.NoError(t, err)
+
+	c := clients.finalizer
+	c.finalizerContext.rewardEpoch = &utils.IntEpoch{Start: 1000, Period: 100}
+	c.finalizerContext.thresholdFallback = newThresholdFallback(&cfg, c.finalizerContext.rewardEpoch)
+	clients.eth.stateData = &relayStateData{
+		firstRewardEpochStartVotingRoundId: 1000,
+		rewardEpochDurationInVotingEpochs:  100,
+		thresholdIncreaseBIPS:              12000,
+		lastInitializedRewardEpoch:         5,
+	}
+	require.NoError(t, c.finalizerContext.thresholdFallback.LoadRelayRules(context.Background(), c.relayClient))
+
+	for _, sp := range []*signingPolicy{
+		{rewardEpochId: 4, startVotingRoundId: 1400, threshold: 500},
+		{rewardEpochId: 5, startVotingRoundId: 1500, threshold: 500},
+	} {
+		sp.voters = voters.NewVoterSet(
+			[]common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}, []uint16{600, 400},
+		)
+		require.NoError(t, c.signingPolicyStorage.Add(sp))
+	}
+	return c
+}
+
+func TestThresholdFallbackStalledSigningPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.ThresholdFallbackConfig
+		expected map[uint32]uint16 // threshold by voting round
+	}{
+		{
+			name:     "relay",
+			cfg:      config.ThresholdFallbackConfig{Mode: config.ThresholdFallbackRelay},
+			expected: map[uint32]uint16{1450: 500, 1599: 500, 1600: 600, 1700: 600},
+		},
+		{
+			name:     "fixed",
+			cfg:      config.ThresholdFallbackConfig{Mode: config.ThresholdFallbackFixed, ThresholdBIPS: 7000, AfterVotingRounds: 10},
+			expected: map[uint32]uint16{1450: 500, 1600: 500, 1609: 500, 1610: 700},
+		},
+		{
+			name:     "fixed below policy threshold",
+			cfg:      config.ThresholdFallbackConfig{Mode: config.ThresholdFallbackFixed, ThresholdBIPS: 3000, AfterVotingRounds: 10},
+			expected: map[uint32]uint16{1450: 500, 1609: 500, 1610: 500, 1700: 500},
+		},
+		{
+			name:     "disabled",
+			cfg:      config.ThresholdFallbackConfig{Mode: config.ThresholdFallbackDisabled},
+			expected: map[uint32]uint16{1450: 500, 1600: 500, 1700: 500},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := stalledSigningPolicyClient(t, test.cfg)
+			for votingRoundId, expected := range test.expected {
+				sp, threshold := c.signingPolicyData(votingRoundId)
+				require.NotNil(t, sp)
+				require.Equal(t, expected, threshold, "voting round %d", votingRoundId)
+			}
+
+			// signing policy of reward epoch 6 is initialized late, its threshold applies from its start
+			sp := &signingPolicy{
+				rewardEpochId: 6, startVotingRoundId: 1650, threshold: 550, voters: voters.NewVoterSet(nil, nil),
+			}
+			require.NoError(t, c.signingPolicyStorage.Add(sp))
+			_, threshold := c.signingPolicyData(1620)
+			require.Equal(t, uint16(500), threshold)
+			_, threshold = c.signingPolicyData(1650)
+			require.Equal(t, uint16(550), threshold)
+		})
+	}
+}
+
+func TestThresholdFallbackStalledSigningPolicyFinalization(t *testing.T) {
+	c := stalledSigningPolicyClient(t, config.ThresholdFallbackConfig{Mode: config.ThresholdFallbackRelay})
+	first := &signedPayload{signer: common.HexToAddress("0x01")}
+	second := &signedPayload{signer: common.HexToAddress("0x02")}
+
+	// weight 600 of the first voter exceeds the signing policy threshold
+	sp, threshold := c.signingPolicyData(1599)
+	message := newMessageData(sp)
+	require.NoError(t, message.addPayload(first, threshold))
+	require.True(t, message.thresholdReached)
+
+	// but not the increased threshold once the next reward epoch is late
+	sp, threshold = c.signingPolicyData(1600)
+	message = newMessageData(sp)
+	require.NoError(t, message.addPayload(first, threshold))
+	require.False(t, message.thresholdReached)
+	require.NoError(t, message.addPayload(second, threshold))
+	require.True(t, message.thresholdReached)
+}
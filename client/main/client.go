@@ -21,6 +21,14 @@ func main() {
 		return
 	}
 
+	if args := clientCtx.Flags().Args; len(args) > 0 {
+		if err := runCommand(clientCtx, args); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Prometheus metrics
 	shared.InitMetricsServer(&clientCtx.Config().Metrics)
 
@@ -0,0 +1,30 @@
+package main
+
+import (
+	clientContext "flare-tlc/client/context"
+	"flare-tlc/client/finalizer"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type command func(ctx clientContext.ClientContext, args []string) error
+
+// Subcommands, run as: client [-config <file>] <command> [command flags]
+var commands = map[string]command{
+	"timeline":      finalizer.TimelineCommand,
+	"select-voters": finalizer.SelectVotersCommand,
+}
+
+func runCommand(ctx clientContext.ClientContext, args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %s, available commands: %s", args[0], strings.Join(names, ", "))
+	}
+	return cmd(ctx, args[1:])
+}
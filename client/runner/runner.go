@@ -7,7 +7,9 @@ import (
 	"flare-tlc/client/epoch"
 	"flare-tlc/client/finalizer"
 	"flare-tlc/client/protocol"
+	"flare-tlc/client/shared"
 	"flare-tlc/logger"
+	"flare-tlc/utils/chain"
 	"reflect"
 	"sync"
 )
@@ -33,6 +35,51 @@ func RunAsync(ctx context.Context, cancel context.CancelFunc, wg *sync.WaitGroup
 }
 
 func Start(ctx context.Context, cancel context.CancelFunc, clientCtx clientContext.ClientContext) *sync.WaitGroup {
+	cfg := clientCtx.Config()
+	if cfg.ContractAddresses.FlareContractRegistry == chain.EmptyAddress {
+		return startClients(ctx, cancel, clientCtx)
+	}
+
+	chainCfg := cfg.ChainConfig()
+	ethClient, err := chainCfg.DialETH()
+	if err != nil {
+		logger.Fatal("Error connecting to chain: %v", err)
+	}
+	discovery, err := shared.NewContractDiscovery(ethClient, cfg.ContractAddresses.FlareContractRegistry)
+	if err != nil {
+		logger.Fatal("Error creating contract discovery: %v", err)
+	}
+	resolved, err := discovery.Resolve(ctx)
+	if err != nil {
+		logger.Fatal("Error resolving contract addresses: %v", err)
+	}
+	shared.UpdateContractAddresses(&cfg.ContractAddresses, resolved, -1)
+	logger.Info("Resolved contract addresses: submission %v, systems manager %v, voter registry %v, relay %v",
+		resolved.Submission, resolved.SystemsManager, resolved.VoterRegistry, resolved.Relay)
+
+	// Clients are restarted with new addresses when a contract address changes
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			clientsCtx, cancelClients := context.WithCancel(ctx)
+			clientsWg := startClients(clientsCtx, cancel, clientCtx)
+			resolved, activeUntilRewardEpoch := discovery.Watch(clientsCtx, &cfg.ContractAddresses)
+			cancelClients()
+			clientsWg.Wait()
+			if resolved == nil || ctx.Err() != nil {
+				return
+			}
+			// clients are stopped, addresses can be updated
+			shared.UpdateContractAddresses(&cfg.ContractAddresses, resolved, activeUntilRewardEpoch)
+			logger.Warn("Contract addresses changed, restarting clients")
+		}
+	}()
+	return &wg
+}
+
+func startClients(ctx context.Context, cancel context.CancelFunc, clientCtx clientContext.ClientContext) *sync.WaitGroup {
 	registrationClient, err := epoch.NewEpochClient(clientCtx)
 	if err != nil {
 		logger.Fatal("Error creating registration client: %v", err)
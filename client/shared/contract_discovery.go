@@ -0,0 +1,156 @@
+package shared
+
+import (
+	"context"
+	"flare-tlc/config"
+	"flare-tlc/logger"
+	"flare-tlc/utils/contracts/contractregistry"
+	"flare-tlc/utils/contracts/system"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Contract names in the FlareContractRegistry
+const (
+	SubmissionContractName     = "Submission"
+	SystemsManagerContractName = "FlareSystemsManager"
+	VoterRegistryContractName  = "VoterRegistry"
+	RelayContractName          = "Relay"
+)
+
+const (
+	// Addresses are re-resolved this long after the start of each reward epoch
+	contractDiscoveryDelay = 30 * time.Second
+	// Retry interval if resolving addresses fails
+	contractDiscoveryRetryInterval = time.Minute
+)
+
+// ContractDiscovery resolves contract addresses by name from the FlareContractRegistry
+type ContractDiscovery struct {
+	ethClient *ethclient.Client
+	registry  *contractregistry.FlareContractRegistry
+}
+
+func NewContractDiscovery(ethClient *ethclient.Client, registryAddress common.Address) (*ContractDiscovery, error) {
+	registry, err := contractregistry.NewFlareContractRegistry(registryAddress, ethClient)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractDiscovery{
+		ethClient: ethClient,
+		registry:  registry,
+	}, nil
+}
+
+// Resolve returns the Submission, FlareSystemsManager, VoterRegistry and Relay addresses
+// registered in the FlareContractRegistry
+func (d *ContractDiscovery) Resolve(ctx context.Context) (*config.ContractAddresses, error) {
+	names := []string{SubmissionContractName, SystemsManagerContractName, VoterRegistryContractName, RelayContractName}
+	addresses, err := d.registry.GetContractAddressesByName(&bind.CallOpts{Context: ctx}, names)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) != len(names) {
+		return nil, fmt.Errorf("unexpected number of addresses returned by the contract registry: %d", len(addresses))
+	}
+	for i, address := range addresses {
+		if address == (common.Address{}) {
+			return nil, fmt.Errorf("contract %s is not registered in the contract registry", names[i])
+		}
+	}
+	return &config.ContractAddresses{
+		Submission:     addresses[0],
+		SystemsManager: addresses[1],
+		VoterRegistry:  addresses[2],
+		Relay:          addresses[3],
+	}, nil
+}
+
+// Watch re-resolves contract addresses at the start of each reward epoch. When an address
+// changes, Watch returns the resolved addresses and the reward epoch until which replaced
+// contracts are active, to be applied with UpdateContractAddresses once clients using the
+// addresses are stopped. Returns nil if the context is cancelled. Addresses are not modified.
+func (d *ContractDiscovery) Watch(ctx context.Context, addresses *config.ContractAddresses) (*config.ContractAddresses, int64) {
+	for {
+		wait := contractDiscoveryRetryInterval
+		rewardEpochId, nextRewardEpochStart, err := d.rewardEpochData(ctx, addresses.SystemsManager)
+		if err != nil {
+			logger.Error("Error fetching reward epoch data for contract discovery: %v", err)
+		} else {
+			wait = time.Until(nextRewardEpochStart.Add(contractDiscoveryDelay))
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, 0
+		}
+
+		resolved, err := d.Resolve(ctx)
+		if err != nil {
+			logger.Error("Error resolving contract addresses: %v", err)
+			continue
+		}
+		if contractAddressesChanged(addresses, resolved) {
+			// replaced contracts may still have been used in the reward epoch that just started
+			return resolved, rewardEpochId + 1
+		}
+	}
+}
+
+func contractAddressesChanged(addresses *config.ContractAddresses, resolved *config.ContractAddresses) bool {
+	return addresses.Submission != resolved.Submission ||
+		addresses.SystemsManager != resolved.SystemsManager ||
+		addresses.VoterRegistry != resolved.VoterRegistry ||
+		addresses.Relay != resolved.Relay
+}
+
+// Returns the current reward epoch id and the start of the next reward epoch
+func (d *ContractDiscovery) rewardEpochData(ctx context.Context, systemsManagerAddress common.Address) (int64, time.Time, error) {
+	fsm, err := system.NewFlareSystemsManager(systemsManagerAddress, d.ethClient)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	epoch, err := RewardEpochFromChain(fsm)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	rewardEpochId, err := fsm.GetCurrentRewardEpochId(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return rewardEpochId.Int64(), epoch.EndTime(epoch.EpochIndex(time.Now())), nil
+}
+
+// UpdateContractAddresses sets resolved addresses and returns true if any address changed.
+// Replaced Submission, FlareSystemsManager and Relay addresses are added to legacy addresses,
+// active until the provided reward epoch, so their events are still read. If activeUntilRewardEpoch
+// is negative, replaced addresses are not kept.
+func UpdateContractAddresses(addresses *config.ContractAddresses, resolved *config.ContractAddresses, activeUntilRewardEpoch int64) bool {
+	changed := false
+	update := func(name string, current *common.Address, newAddress common.Address, legacy *[]config.LegacyContractAddress) {
+		if *current == newAddress {
+			return
+		}
+		if *current != (common.Address{}) {
+			logger.Warn("Contract %s address changed from %v to %v", name, *current, newAddress)
+			if legacy != nil && activeUntilRewardEpoch >= 0 {
+				*legacy = append(*legacy, config.LegacyContractAddress{
+					Address:                *current,
+					ActiveUntilRewardEpoch: &activeUntilRewardEpoch,
+				})
+			}
+		}
+		*current = newAddress
+		changed = true
+	}
+	update(SubmissionContractName, &addresses.Submission, resolved.Submission, &addresses.LegacySubmissions)
+	update(SystemsManagerContractName, &addresses.SystemsManager, resolved.SystemsManager, &addresses.LegacySystemsManagers)
+	update(VoterRegistryContractName, &addresses.VoterRegistry, resolved.VoterRegistry, nil)
+	update(RelayContractName, &addresses.Relay, resolved.Relay, &addresses.LegacyRelays)
+	return changed
+}
@@ -0,0 +1,37 @@
+package shared
+
+import (
+	"flare-tlc/config"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateContractAddresses(t *testing.T) {
+	addresses := &config.ContractAddresses{
+		Submission:     common.HexToAddress("0x01"),
+		SystemsManager: common.HexToAddress("0x02"),
+		VoterRegistry:  common.HexToAddress("0x03"),
+		Relay:          common.HexToAddress("0x04"),
+	}
+	resolved := *addresses
+	require.False(t, contractAddressesChanged(addresses, &resolved))
+	require.False(t, UpdateContractAddresses(addresses, &resolved, 10))
+
+	resolved.Relay = common.HexToAddress("0x14")
+	resolved.VoterRegistry = common.HexToAddress("0x13")
+	require.True(t, contractAddressesChanged(addresses, &resolved))
+	require.True(t, UpdateContractAddresses(addresses, &resolved, 10))
+	require.Equal(t, resolved.Relay, addresses.Relay)
+	require.Equal(t, resolved.VoterRegistry, addresses.VoterRegistry)
+	require.Equal(t, []config.LegacyContractAddress{
+		{Address: common.HexToAddress("0x04"), ActiveUntilRewardEpoch: ptr(int64(10))},
+	}, addresses.LegacyRelays)
+	require.Empty(t, addresses.LegacySubmissions)
+
+	// replaced addresses are not kept
+	resolved.Submission = common.HexToAddress("0x11")
+	require.True(t, UpdateContractAddresses(addresses, &resolved, -1))
+	require.Empty(t, addresses.LegacySubmissions)
+}
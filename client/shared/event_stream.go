@@ -0,0 +1,133 @@
+package shared
+
+import (
+	"context"
+	"flare-tlc/database"
+	"flare-tlc/logger"
+	"flare-tlc/utils"
+	"sort"
+	"time"
+)
+
+// EventStream polls the indexer database for logs with the topic emitted by a contract and its
+// legacy deployments, and sends parsed events to a channel in the order they were emitted.
+// Logs are identified by transaction hash and log index, each log is handled only once, also
+// if more logs with the same timestamp are indexed after the previous poll.
+type EventStream[T any] struct {
+	Name        string // event name, used in log messages
+	History     *ContractHistory
+	RewardEpoch *utils.Epoch // used to determine active legacy deployments, may be nil if there are none
+	Topic0      string
+	Parse       func(database.Log) (T, error)
+
+	// topic0 of other events of the contract sent in the same stream, logs with equal timestamps
+	// are ordered by log index, which is exact only for logs in the same block
+	ExtraTopic0s []string
+
+	Filter     func(T) bool  // optional, events it returns false for are not sent
+	Interval   time.Duration // poll interval, EventListenerInterval if zero
+	StartDelay time.Duration // delay before the first poll
+	BufferSize int           // size of the output channel, polling stops while the channel is full
+}
+
+type eventLogKey struct {
+	txHash   string
+	logIndex uint64
+}
+
+// Start starts polling for events emitted after the start time. Polling stops when the context is
+// cancelled, the returned channel is not closed.
+func (s *EventStream[T]) Start(ctx context.Context, db LogsFetcher, from time.Time) <-chan T {
+	out := make(chan T, s.BufferSize)
+	go s.run(ctx, db, from.Unix(), out)
+	return out
+}
+
+func (s *EventStream[T]) run(ctx context.Context, db LogsFetcher, from int64, out chan<- T) {
+	if s.StartDelay > 0 {
+		select {
+		case <-time.After(s.StartDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+	interval := s.Interval
+	if interval == 0 {
+		interval = EventListenerInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// logs with timestamp equal to from that were already handled
+	seen := make(map[eventLogKey]bool)
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		rangeStart := from
+		if len(seen) > 0 {
+			// include the last timestamp again, logs with it may have been indexed since
+			rangeStart = from - 1
+		}
+		logs, err := s.fetchLogs(db, rangeStart, time.Now().Unix())
+		if err != nil {
+			logger.Error("Error fetching %s logs %v", s.Name, err)
+			continue
+		}
+
+		var events []T
+		for _, log := range logs {
+			key := eventLogKey{txHash: log.TransactionHash, logIndex: log.LogIndex}
+			if seen[key] {
+				continue
+			}
+			if ts := int64(log.Timestamp); ts > from {
+				from = ts
+				seen = make(map[eventLogKey]bool)
+			}
+			seen[key] = true
+
+			event, err := s.Parse(log)
+			if err != nil {
+				logger.Error("Error parsing %s event %v", s.Name, err)
+				continue
+			}
+			if s.Filter != nil && !s.Filter(event) {
+				continue
+			}
+			events = append(events, event)
+		}
+
+		for _, event := range events {
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (s *EventStream[T]) fetchLogs(db LogsFetcher, from, to int64) ([]database.Log, error) {
+	logs, err := s.History.FetchLogs(db, s.Topic0, from, to, s.RewardEpoch)
+	if err != nil || len(s.ExtraTopic0s) == 0 {
+		return logs, err
+	}
+	for _, topic0 := range s.ExtraTopic0s {
+		topicLogs, err := s.History.FetchLogs(db, topic0, from, to, s.RewardEpoch)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, topicLogs...)
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].Timestamp != logs[j].Timestamp {
+			return logs[i].Timestamp < logs[j].Timestamp
+		}
+		return logs[i].LogIndex < logs[j].LogIndex
+	})
+	return logs, nil
+}
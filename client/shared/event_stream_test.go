@@ -0,0 +1,131 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"flare-tlc/database"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns logs in the requested time range, logs can be added while the stream is running
+type testStreamLogsFetcher struct {
+	logs []database.Log
+	sync.Mutex
+}
+
+func (f *testStreamLogsFetcher) FetchLogsByAddressAndTopic0(_ common.Address, topic0 string, from, to int64) ([]database.Log, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	var result []database.Log
+	for _, log := range f.logs {
+		if log.Topic0 == topic0 && int64(log.Timestamp) > from && int64(log.Timestamp) <= to {
+			result = append(result, log)
+		}
+	}
+	return result, nil
+}
+
+func (f *testStreamLogsFetcher) add(logs ...database.Log) {
+	f.Lock()
+	defer f.Unlock()
+	f.logs = append(f.logs, logs...)
+}
+
+func receiveEvents(t *testing.T, events <-chan uint64, n int) []uint64 {
+	var result []uint64
+	for i := 0; i < n; i++ {
+		select {
+		case event := <-events:
+			result = append(result, event)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of %d events", i, n)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %d", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+	return result
+}
+
+func TestEventStream(t *testing.T) {
+	now := uint64(time.Now().Unix())
+	db := &testStreamLogsFetcher{}
+	db.add(
+		database.Log{TransactionHash: "a", LogIndex: 0, Timestamp: now - 100}, // before start
+		database.Log{TransactionHash: "b", LogIndex: 0, Timestamp: now - 10},
+		database.Log{TransactionHash: "b", LogIndex: 1, Timestamp: now - 10, Data: "invalid"},
+		database.Log{TransactionHash: "c", LogIndex: 0, Timestamp: now - 5},
+	)
+
+	stream := &EventStream[uint64]{
+		Name:    "Test",
+		History: NewContractHistory(common.Address{}, nil),
+		Parse: func(log database.Log) (uint64, error) {
+			if log.Data == "invalid" {
+				return 0, errors.New("invalid log")
+			}
+			return log.Timestamp*10 + log.LogIndex, nil
+		},
+		Filter:   func(event uint64) bool { return event%10 != 5 },
+		Interval: 10 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := stream.Start(ctx, db, time.Unix(int64(now-50), 0))
+
+	require.Equal(t, []uint64{(now-10)*10 + 0, (now-5)*10 + 0}, receiveEvents(t, events, 2))
+
+	// log with the last seen timestamp indexed after the previous poll, filtered log
+	db.add(
+		database.Log{TransactionHash: "c", LogIndex: 1, Timestamp: now - 5},
+		database.Log{TransactionHash: "d", LogIndex: 5, Timestamp: now - 5},
+	)
+	require.Equal(t, []uint64{(now-5)*10 + 1}, receiveEvents(t, events, 1))
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	db.add(database.Log{TransactionHash: "e", LogIndex: 0, Timestamp: now})
+	receiveEvents(t, events, 0)
+}
+
+func TestEventStreamExtraTopics(t *testing.T) {
+	now := uint64(time.Now().Unix())
+	db := &testStreamLogsFetcher{}
+	db.add(
+		database.Log{Topic0: "a", TransactionHash: "3", LogIndex: 0, Timestamp: now - 5},
+		database.Log{Topic0: "b", TransactionHash: "1", LogIndex: 0, Timestamp: now - 10},
+		database.Log{Topic0: "a", TransactionHash: "2", LogIndex: 2, Timestamp: now - 10},
+		database.Log{Topic0: "b", TransactionHash: "2", LogIndex: 1, Timestamp: now - 10},
+	)
+
+	stream := &EventStream[string]{
+		Name:         "Test",
+		History:      NewContractHistory(common.Address{}, nil),
+		Topic0:       "a",
+		ExtraTopic0s: []string{"b"},
+		Parse:        func(log database.Log) (string, error) { return log.Topic0 + log.TransactionHash, nil },
+		Interval:     10 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := stream.Start(ctx, db, time.Unix(int64(now-50), 0))
+
+	var received []string
+	for i := 0; i < 4; i++ {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of 4 events", i)
+		}
+	}
+	require.Equal(t, []string{"b1", "b2", "a2", "a3"}, received)
+}
@@ -0,0 +1,65 @@
+package shared
+
+import (
+	"flare-tlc/config"
+	"flare-tlc/database"
+	"flare-tlc/utils"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type LogsFetcher interface {
+	FetchLogsByAddressAndTopic0(common.Address, string, int64, int64) ([]database.Log, error)
+}
+
+// Address of a contract and addresses of its previous deployments
+type ContractHistory struct {
+	Current common.Address
+	Legacy  []config.LegacyContractAddress
+}
+
+func NewContractHistory(current common.Address, legacy []config.LegacyContractAddress) *ContractHistory {
+	return &ContractHistory{
+		Current: current,
+		Legacy:  legacy,
+	}
+}
+
+// Addresses returns the addresses that may have emitted events after the provided time,
+// legacy deployments first. Legacy contracts are considered active until the end of the reward
+// epoch following their last active reward epoch, since reward epochs may last longer than expected.
+func (h *ContractHistory) Addresses(from time.Time, rewardEpoch *utils.Epoch) []common.Address {
+	if h == nil {
+		return nil
+	}
+	addresses := make([]common.Address, 0, len(h.Legacy)+1)
+	for _, legacy := range h.Legacy {
+		if legacy.ActiveUntilRewardEpoch != nil && rewardEpoch != nil &&
+			!from.Before(rewardEpoch.EndTime(*legacy.ActiveUntilRewardEpoch+1)) {
+			continue
+		}
+		addresses = append(addresses, legacy.Address)
+	}
+	return append(addresses, h.Current)
+}
+
+// FetchLogs returns logs with the topic emitted in the time range (from, to] by the contract
+// and its active legacy deployments, ordered by timestamp
+func (h *ContractHistory) FetchLogs(
+	db LogsFetcher, topic0 string, from, to int64, rewardEpoch *utils.Epoch,
+) ([]database.Log, error) {
+	var logs []database.Log
+	for _, address := range h.Addresses(time.Unix(from, 0), rewardEpoch) {
+		addressLogs, err := db.FetchLogsByAddressAndTopic0(address, topic0, from, to)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, addressLogs...)
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp < logs[j].Timestamp
+	})
+	return logs, nil
+}
@@ -0,0 +1,48 @@
+package shared
+
+import (
+	"flare-tlc/config"
+	"flare-tlc/database"
+	"flare-tlc/utils"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type testLogsFetcher map[common.Address][]database.Log
+
+func (f testLogsFetcher) FetchLogsByAddressAndTopic0(address common.Address, _ string, _, _ int64) ([]database.Log, error) {
+	return f[address], nil
+}
+
+func TestContractHistory(t *testing.T) {
+	current := common.HexToAddress("0x03")
+	legacyAlways := common.HexToAddress("0x01")
+	legacyUntil := common.HexToAddress("0x02")
+	legacyFirst := common.HexToAddress("0x04")
+	h := NewContractHistory(current, []config.LegacyContractAddress{
+		{Address: legacyAlways},
+		{Address: legacyUntil, ActiveUntilRewardEpoch: ptr(int64(5))},
+		{Address: legacyFirst, ActiveUntilRewardEpoch: ptr(int64(0))},
+	})
+	rewardEpoch := utils.NewEpoch(time.Unix(0, 0), 100*time.Second)
+
+	// legacy contract is read until the end of the reward epoch following the last active one
+	require.Equal(t, []common.Address{legacyAlways, legacyUntil, legacyFirst, current}, h.Addresses(time.Unix(199, 0), rewardEpoch))
+	require.Equal(t, []common.Address{legacyAlways, legacyUntil, current}, h.Addresses(time.Unix(699, 0), rewardEpoch))
+	require.Equal(t, []common.Address{legacyAlways, current}, h.Addresses(time.Unix(700, 0), rewardEpoch))
+
+	logs, err := h.FetchLogs(testLogsFetcher{
+		current:     {{Timestamp: 650}},
+		legacyUntil: {{Timestamp: 600}, {Timestamp: 660}},
+	}, "", 500, 800, rewardEpoch)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+	require.Equal(t, []uint64{600, 650, 660}, []uint64{logs[0].Timestamp, logs[1].Timestamp, logs[2].Timestamp})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -1,10 +1,13 @@
 package shared
 
 import (
+	"encoding/json"
 	"flare-tlc/client/config"
+	"flare-tlc/logger"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
@@ -51,6 +54,7 @@ func InitMetricsServer(cfg *config.MetricsConfig) {
 
 	r.Path("/metrics").Handler(promhttp.Handler())
 	r.Path("/health").HandlerFunc(healthHandler)
+	r.PathPrefix(apiPathPrefix).HandlerFunc(apiHandler)
 
 	srv := &http.Server{
 		Addr:    cfg.PrometheusAddress,
@@ -105,3 +109,41 @@ func getHealthStatus() (bool, error) {
 	}
 	return true, nil
 }
+
+const apiPathPrefix = "/api/"
+
+var (
+	apiHandlers   = make(map[string]http.HandlerFunc)
+	apiHandlersMu sync.RWMutex
+)
+
+// RegisterAPIHandler exposes the handler on the metrics server at /api/<path>.
+// Handlers can be registered before or after the metrics server is started.
+func RegisterAPIHandler(path string, handler http.HandlerFunc) {
+	apiHandlersMu.Lock()
+	defer apiHandlersMu.Unlock()
+
+	apiHandlers[strings.Trim(path, "/")] = handler
+}
+
+func apiHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPathPrefix), "/")
+
+	apiHandlersMu.RLock()
+	handler, ok := apiHandlers[path]
+	apiHandlersMu.RUnlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler(w, r)
+}
+
+// WriteJSONResponse writes value as a JSON response body
+func WriteJSONResponse(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logger.Error("Error writing API response: %v", err)
+	}
+}
@@ -86,6 +86,23 @@ type ContractAddresses struct {
 	SystemsManager common.Address `toml:"systems_manager" envconfig:"SYSTEMS_MANAGER_CONTRACT_ADDRESS"`
 	VoterRegistry  common.Address `toml:"voter_registry" envconfig:"VOTER_REGISTRY_CONTRACT_ADDRESS"`
 	Relay          common.Address `toml:"relay" envconfig:"RELAY_CONTRACT_ADDRESS"`
+
+	// If set, the addresses above are resolved from the FlareContractRegistry at startup and
+	// at the start of each reward epoch
+	FlareContractRegistry common.Address `toml:"flare_contract_registry" envconfig:"FLARE_CONTRACT_REGISTRY_ADDRESS"`
+
+	// Previous deployments of the contracts, their events are read in addition to the events
+	// of the current contracts
+	LegacyRelays          []LegacyContractAddress `toml:"legacy_relays"`
+	LegacySubmissions     []LegacyContractAddress `toml:"legacy_submissions"`
+	LegacySystemsManagers []LegacyContractAddress `toml:"legacy_systems_managers"`
+}
+
+type LegacyContractAddress struct {
+	Address common.Address `toml:"address"`
+	// Last reward epoch in which the contract was used, events are read until the end of
+	// the following reward epoch. If not set, events are always read.
+	ActiveUntilRewardEpoch *int64 `toml:"active_until_reward_epoch"`
 }
 
 func ParseConfigFile(cfg interface{}, fileName string, allowMissing bool) error {
@@ -0,0 +1,77 @@
+package chain
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// BlockNumberByTimestamp returns the number of the last block with timestamp <= ts,
+// using binary search over block headers. Returns 0 if all blocks are newer.
+func BlockNumberByTimestamp(ctx context.Context, client *ethclient.Client, ts uint64) (uint64, error) {
+	number, _, err := blockNumberByTimestamp(ctx, client, ts)
+	return number, err
+}
+
+// Also returns whether the result is final, i.e. a newer block than the result already exists
+func blockNumberByTimestamp(ctx context.Context, client *ethclient.Client, ts uint64) (uint64, bool, error) {
+	latest, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	if latest.Time <= ts {
+		return latest.Number.Uint64(), false, nil
+	}
+
+	// invariant: block low has timestamp <= ts (or low is 0), block high has timestamp > ts
+	low, high := uint64(0), latest.Number.Uint64()
+	for high-low > 1 {
+		mid := low + (high-low)/2
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, false, err
+		}
+		if header.Time <= ts {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return low, true, nil
+}
+
+// BlockNumberCache caches results of BlockNumberByTimestamp. Only final results are cached,
+// timestamps not older than the latest block are searched again on each call.
+type BlockNumberCache struct {
+	client  *ethclient.Client
+	numbers map[uint64]uint64
+
+	sync.Mutex
+}
+
+func NewBlockNumberCache(client *ethclient.Client) *BlockNumberCache {
+	return &BlockNumberCache{
+		client:  client,
+		numbers: make(map[uint64]uint64),
+	}
+}
+
+func (c *BlockNumberCache) BlockNumberByTimestamp(ctx context.Context, ts uint64) (uint64, error) {
+	c.Lock()
+	number, ok := c.numbers[ts]
+	c.Unlock()
+	if ok {
+		return number, nil
+	}
+
+	number, final, err := blockNumberByTimestamp(ctx, c.client, ts)
+	if err != nil || !final {
+		return number, err
+	}
+	c.Lock()
+	c.numbers[ts] = number
+	c.Unlock()
+	return number, nil
+}
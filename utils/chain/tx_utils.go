@@ -7,6 +7,7 @@ import (
 	"flare-tlc/client/config"
 	"flare-tlc/logger"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -85,16 +86,62 @@ func unpackError(result []byte) (string, error) {
 }
 
 func SendRawTx(client *ethclient.Client, privateKey *ecdsa.PrivateKey, toAddress common.Address, data []byte, dryRun bool, gasConfig *config.GasConfig, timeout time.Duration) error {
+	_, err := sendRawTx(client, privateKey, toAddress, data, dryRun, gasConfig, timeout, nil)
+	return err
+}
+
+// SendRawTxConcurrent is like SendRawTx but can be called concurrently for the same sender.
+// Nonce assignment and tx broadcast are serialized by nonceLock (which should be shared by all
+// senders using the same private key), waiting for the tx to be mined is not.
+// Returns the receipt of the mined tx.
+func SendRawTxConcurrent(client *ethclient.Client, nonceLock *sync.Mutex, privateKey *ecdsa.PrivateKey, toAddress common.Address, data []byte, dryRun bool, gasConfig *config.GasConfig, timeout time.Duration) (*types.Receipt, error) {
+	return sendRawTx(client, privateKey, toAddress, data, dryRun, gasConfig, timeout, nonceLock)
+}
+
+func sendRawTx(client *ethclient.Client, privateKey *ecdsa.PrivateKey, toAddress common.Address, data []byte, dryRun bool, gasConfig *config.GasConfig, timeout time.Duration, nonceLock *sync.Mutex) (*types.Receipt, error) {
 	publicKey := privateKey.Public()
 	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
 	if !ok {
-		return errors.New("cannot assert type: publicKey is not of type *ecdsa.PublicKey")
+		return nil, errors.New("cannot assert type: publicKey is not of type *ecdsa.PublicKey")
 	}
 
 	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.NonceAt(context.Background(), fromAddress, nil)
+	signedTx, err := signAndSendTx(client, privateKey, fromAddress, toAddress, data, dryRun, gasConfig, nonceLock)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier := NewTxVerifier(client)
+
+	logger.Debug("Waiting for tx to be mined...")
+	err = verifier.WaitUntilMined(fromAddress, signedTx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("Tx mined, getting receipt %s", signedTx.Hash().Hex())
+	rec, err := client.TransactionReceipt(context.Background(), signedTx.Hash())
 	if err != nil {
-		return err
+		return nil, err
+	}
+	logger.Debug("Receipt status: %v", rec.Status)
+	return rec, nil
+}
+
+func signAndSendTx(client *ethclient.Client, privateKey *ecdsa.PrivateKey, fromAddress common.Address, toAddress common.Address, data []byte, dryRun bool, gasConfig *config.GasConfig, nonceLock *sync.Mutex) (*types.Transaction, error) {
+	var nonce uint64
+	var err error
+	if nonceLock != nil {
+		nonceLock.Lock()
+		defer nonceLock.Unlock()
+
+		// include txs of concurrent senders that are not mined yet
+		nonce, err = client.PendingNonceAt(context.Background(), fromAddress)
+	} else {
+		nonce, err = client.NonceAt(context.Background(), fromAddress, nil)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	value := big.NewInt(0) // in wei (1 eth)
@@ -102,49 +149,34 @@ func SendRawTx(client *ethclient.Client, privateKey *ecdsa.PrivateKey, toAddress
 	if dryRun {
 		err = dryRunTx(client, fromAddress, toAddress, value, data)
 		if err != nil {
-			return errors.Wrap(err, "dry run failed")
+			return nil, errors.Wrap(err, "dry run failed")
 		}
 	}
 
 	gasLimit := getGasLimit(gasConfig, client, fromAddress, toAddress, value, data)
 	gasPrice, err := GetGasPrice(gasConfig, client)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	tx := types.NewTransaction(nonce, toAddress, value, gasLimit, gasPrice, data)
 
 	chainID, err := client.NetworkID(context.Background())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	logger.Debug("Sending signed tx: %s", signedTx.Hash().Hex())
 	err = client.SendTransaction(context.Background(), signedTx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	verifier := NewTxVerifier(client)
-
-	logger.Debug("Waiting for tx to be mined...")
-	err = verifier.WaitUntilMined(fromAddress, signedTx, timeout)
-	if err != nil {
-		return err
-	}
-
-	logger.Debug("Tx mined, getting receipt %s", signedTx.Hash().Hex())
-	rec, err := client.TransactionReceipt(context.Background(), signedTx.Hash())
-	if err != nil {
-		return err
-	}
-	logger.Debug("Receipt status: %v", rec.Status)
-	return nil
+	return signedTx, nil
 }
 
 func dryRunTx(client *ethclient.Client, fromAddress common.Address, toAddress common.Address, value *big.Int, data []byte) error {
@@ -0,0 +1,2 @@
+//go:generate  abigen --abi=contractregistry.abi --pkg=contractregistry --type=FlareContractRegistry --out=autogen.go
+package contractregistry
@@ -0,0 +1,212 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package entity
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// EntityManagerMetaData contains all meta data concerning the EntityManager contract.
+var EntityManagerMetaData = &bind.MetaData{
+	ABI: "[{\"type\":\"function\",\"name\":\"getPublicKeyOfAt\",\"stateMutability\":\"view\",\"inputs\":[{\"type\":\"address\",\"name\":\"_voter\",\"internalType\":\"address\"},{\"type\":\"uint256\",\"name\":\"_blockNumber\",\"internalType\":\"uint256\"}],\"outputs\":[{\"type\":\"bytes32\",\"name\":\"\",\"internalType\":\"bytes32\"},{\"type\":\"bytes32\",\"name\":\"\",\"internalType\":\"bytes32\"}]}]",
+}
+
+// EntityManagerABI is the input ABI used to generate the binding from.
+// Deprecated: Use EntityManagerMetaData.ABI instead.
+var EntityManagerABI = EntityManagerMetaData.ABI
+
+// EntityManager is an auto generated Go binding around an Ethereum contract.
+type EntityManager struct {
+	EntityManagerCaller     // Read-only binding to the contract
+	EntityManagerTransactor // Write-only binding to the contract
+	EntityManagerFilterer   // Log filterer for contract events
+}
+
+// EntityManagerCaller is an auto generated read-only Go binding around an Ethereum contract.
+type EntityManagerCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// EntityManagerTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type EntityManagerTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// EntityManagerFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type EntityManagerFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// EntityManagerSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type EntityManagerSession struct {
+	Contract     *EntityManager    // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// EntityManagerCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type EntityManagerCallerSession struct {
+	Contract *EntityManagerCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts        // Call options to use throughout this session
+}
+
+// EntityManagerTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type EntityManagerTransactorSession struct {
+	Contract     *EntityManagerTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts        // Transaction auth options to use throughout this session
+}
+
+// EntityManagerRaw is an auto generated low-level Go binding around an Ethereum contract.
+type EntityManagerRaw struct {
+	Contract *EntityManager // Generic contract binding to access the raw methods on
+}
+
+// EntityManagerCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type EntityManagerCallerRaw struct {
+	Contract *EntityManagerCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// EntityManagerTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type EntityManagerTransactorRaw struct {
+	Contract *EntityManagerTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewEntityManager creates a new instance of EntityManager, bound to a specific deployed contract.
+func NewEntityManager(address common.Address, backend bind.ContractBackend) (*EntityManager, error) {
+	contract, err := bindEntityManager(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &EntityManager{EntityManagerCaller: EntityManagerCaller{contract: contract}, EntityManagerTransactor: EntityManagerTransactor{contract: contract}, EntityManagerFilterer: EntityManagerFilterer{contract: contract}}, nil
+}
+
+// NewEntityManagerCaller creates a new read-only instance of EntityManager, bound to a specific deployed contract.
+func NewEntityManagerCaller(address common.Address, caller bind.ContractCaller) (*EntityManagerCaller, error) {
+	contract, err := bindEntityManager(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EntityManagerCaller{contract: contract}, nil
+}
+
+// NewEntityManagerTransactor creates a new write-only instance of EntityManager, bound to a specific deployed contract.
+func NewEntityManagerTransactor(address common.Address, transactor bind.ContractTransactor) (*EntityManagerTransactor, error) {
+	contract, err := bindEntityManager(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EntityManagerTransactor{contract: contract}, nil
+}
+
+// NewEntityManagerFilterer creates a new log filterer instance of EntityManager, bound to a specific deployed contract.
+func NewEntityManagerFilterer(address common.Address, filterer bind.ContractFilterer) (*EntityManagerFilterer, error) {
+	contract, err := bindEntityManager(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &EntityManagerFilterer{contract: contract}, nil
+}
+
+// bindEntityManager binds a generic wrapper to an already deployed contract.
+func bindEntityManager(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(EntityManagerABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_EntityManager *EntityManagerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _EntityManager.Contract.EntityManagerCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_EntityManager *EntityManagerRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EntityManager.Contract.EntityManagerTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_EntityManager *EntityManagerRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _EntityManager.Contract.EntityManagerTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_EntityManager *EntityManagerCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _EntityManager.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_EntityManager *EntityManagerTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EntityManager.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_EntityManager *EntityManagerTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _EntityManager.Contract.contract.Transact(opts, method, params...)
+}
+
+// GetPublicKeyOfAt is a free data retrieval call binding the contract method 0x11104561.
+//
+// Solidity: function getPublicKeyOfAt(address _voter, uint256 _blockNumber) view returns(bytes32, bytes32)
+func (_EntityManager *EntityManagerCaller) GetPublicKeyOfAt(opts *bind.CallOpts, _voter common.Address, _blockNumber *big.Int) ([32]byte, [32]byte, error) {
+	var out []interface{}
+	err := _EntityManager.contract.Call(opts, &out, "getPublicKeyOfAt", _voter, _blockNumber)
+
+	if err != nil {
+		return *new([32]byte), *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+	out1 := *abi.ConvertType(out[1], new([32]byte)).(*[32]byte)
+
+	return out0, out1, err
+
+}
+
+// GetPublicKeyOfAt is a free data retrieval call binding the contract method 0x11104561.
+//
+// Solidity: function getPublicKeyOfAt(address _voter, uint256 _blockNumber) view returns(bytes32, bytes32)
+func (_EntityManager *EntityManagerSession) GetPublicKeyOfAt(_voter common.Address, _blockNumber *big.Int) ([32]byte, [32]byte, error) {
+	return _EntityManager.Contract.GetPublicKeyOfAt(&_EntityManager.CallOpts, _voter, _blockNumber)
+}
+
+// GetPublicKeyOfAt is a free data retrieval call binding the contract method 0x11104561.
+//
+// Solidity: function getPublicKeyOfAt(address _voter, uint256 _blockNumber) view returns(bytes32, bytes32)
+func (_EntityManager *EntityManagerCallerSession) GetPublicKeyOfAt(_voter common.Address, _blockNumber *big.Int) ([32]byte, [32]byte, error) {
+	return _EntityManager.Contract.GetPublicKeyOfAt(&_EntityManager.CallOpts, _voter, _blockNumber)
+}
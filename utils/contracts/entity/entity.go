@@ -0,0 +1,2 @@
+//go:generate  abigen --abi=entity.abi --pkg=entity --type=EntityManager --out=autogen.go
+package entity
@@ -1,18 +1,26 @@
 package utils
 
 import (
+	"container/heap"
+	"context"
 	"flare-tlc/logger"
 	"sync"
 	"time"
 )
 
-type QueueProcessorFunc[T any] func([]T) error
+type QueueProcessorFunc[T any] func(context.Context, []T) error
 
+// DelayedQueueManager calls the processor with all items added for the same time
+// once that time is reached. All times are handled by a single scheduler, see Run.
 type DelayedQueueManager[T any] struct {
 	timeMap map[time.Time][]T
+	times   timeHeap
 
 	processor QueueProcessorFunc[T]
 
+	// signals the scheduler that a new time was added
+	wakeup chan struct{}
+
 	sync.Mutex
 }
 
@@ -20,6 +28,7 @@ func NewDelayedQueueManager[T any](processor QueueProcessorFunc[T]) *DelayedQueu
 	return &DelayedQueueManager[T]{
 		timeMap:   make(map[time.Time][]T),
 		processor: processor,
+		wakeup:    make(chan struct{}, 1),
 	}
 }
 
@@ -32,27 +41,109 @@ func (l *DelayedQueueManager[T]) Add(t time.Time, item T) {
 	defer l.Unlock()
 
 	if _, ok := l.timeMap[t]; !ok {
-		l.createTimer(t)
+		heap.Push(&l.times, t)
 	}
 	l.timeMap[t] = append(l.timeMap[t], item)
+
+	select {
+	case l.wakeup <- struct{}{}:
+	default:
+	}
 }
 
-func (l *DelayedQueueManager[T]) Get(t time.Time) []T {
+// Len returns the number of scheduled items
+func (l *DelayedQueueManager[T]) Len() int {
 	l.Lock()
 	defer l.Unlock()
 
-	items := l.timeMap[t]
-	delete(l.timeMap, t)
-	return items
+	n := 0
+	for _, items := range l.timeMap {
+		n += len(items)
+	}
+	return n
 }
 
-func (l *DelayedQueueManager[T]) createTimer(t time.Time) {
-	go func() {
-		timer := time.NewTimer(time.Until(t))
-		<-timer.C
-		items := l.Get(t)
-		if err := l.processor(items); err != nil {
-			logger.Error("DelayedQueueManager processor error: %s", err)
+// Run schedules processing of added items, blocks until the context is cancelled.
+// Items are processed in separate goroutines with the provided context, Run waits
+// for them to finish before returning.
+func (l *DelayedQueueManager[T]) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		var timerC <-chan time.Time
+		var timer *time.Timer
+		if next, ok := l.next(); ok {
+			timer = time.NewTimer(time.Until(next))
+			timerC = timer.C
+		}
+
+		select {
+		case <-timerC:
+			for _, items := range l.popDue(time.Now()) {
+				wg.Add(1)
+				go func(items []T) {
+					defer wg.Done()
+					if err := l.processor(ctx, items); err != nil {
+						logger.Error("DelayedQueueManager processor error: %s", err)
+					}
+				}(items)
+			}
+
+		case <-l.wakeup:
+
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return ctx.Err()
+		}
+
+		if timer != nil {
+			timer.Stop()
 		}
-	}()
+	}
+}
+
+func (l *DelayedQueueManager[T]) next() (time.Time, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	if len(l.times) == 0 {
+		return time.Time{}, false
+	}
+	return l.times[0], true
+}
+
+// Removes and returns items for all times <= now, grouped by time
+func (l *DelayedQueueManager[T]) popDue(now time.Time) [][]T {
+	l.Lock()
+	defer l.Unlock()
+
+	var result [][]T
+	for len(l.times) > 0 && !l.times[0].After(now) {
+		t := heap.Pop(&l.times).(time.Time)
+		result = append(result, l.timeMap[t])
+		delete(l.timeMap, t)
+	}
+	return result
+}
+
+// Min-heap of times, implements heap.Interface
+type timeHeap []time.Time
+
+func (h timeHeap) Len() int { return len(h) }
+
+func (h timeHeap) Less(i, j int) bool { return h[i].Before(h[j]) }
+
+func (h timeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *timeHeap) Push(x any) { *h = append(*h, x.(time.Time)) }
+
+func (h *timeHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
 }
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDelayedQueueManager(t *testing.T) {
+	processed := make(chan []int, 10)
+	manager := NewDelayedQueueManager[int](func(ctx context.Context, items []int) error {
+		processed <- items
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- manager.Run(ctx)
+	}()
+
+	now := time.Now()
+	manager.Add(now.Add(100*time.Millisecond), 1)
+	manager.Add(now.Add(100*time.Millisecond), 2)
+	manager.Add(now.Add(50*time.Millisecond), 3)
+	manager.Add(now.Add(-time.Second), 4) // in the past, ignored
+	if manager.Len() != 3 {
+		t.Fatalf("Expected 3 scheduled items, got %d", manager.Len())
+	}
+
+	expected := [][]int{{3}, {1, 2}}
+	for _, e := range expected {
+		select {
+		case items := <-processed:
+			if len(items) != len(e) || items[0] != e[0] || items[len(items)-1] != e[len(e)-1] {
+				t.Fatalf("Expected items %v, got %v", e, items)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for items %v", e)
+		}
+	}
+
+	manager.Add(time.Now().Add(time.Hour), 5)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Scheduler did not stop")
+	}
+	if len(processed) != 0 {
+		t.Fatal("Unexpected processed items after cancellation")
+	}
+}